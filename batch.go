@@ -0,0 +1,111 @@
+package solarman
+
+import (
+	"context"
+	"sort"
+)
+
+// -----------------------------------------------------------------------------
+// Sparse multi-register reads
+// -----------------------------------------------------------------------------
+
+// DefaultReadGap is the default number of unrequested registers
+// read in between to save a round trip
+const DefaultReadGap = 10
+
+// ReadRange is a block of consecutive registers read with one request
+type ReadRange struct {
+	Start int
+	Count int
+}
+
+// PlanReads groups registers into the fewest ranges. Neighbours are merged
+// while the gap of unrequested registers between them is at most maxGap and
+// the range stays within maxCount registers. Duplicates are ignored.
+func PlanReads(registers []int, maxGap int, maxCount int) []ReadRange {
+	if len(registers) == 0 {
+		return nil
+	}
+	if maxCount <= 0 || maxCount > MaxModbusRegisters {
+		maxCount = MaxModbusRegisters
+	}
+
+	sorted := append([]int(nil), registers...)
+	sort.Ints(sorted)
+
+	ranges := []ReadRange{{Start: sorted[0], Count: 1}}
+
+	for _, reg := range sorted[1:] {
+		last := &ranges[len(ranges)-1]
+		end := last.Start + last.Count // first register after the range
+
+		switch {
+		case reg < end:
+			// duplicate
+		case reg-end <= maxGap && reg-last.Start+1 <= maxCount:
+			last.Count = reg - last.Start + 1
+		default:
+			ranges = append(ranges, ReadRange{Start: reg, Count: 1})
+		}
+	}
+
+	return ranges
+}
+
+// SetReadGap sets how many unrequested registers ReadMany may read
+// to join two requested ones into a single request. Keep it low for
+// devices with holes in the register map, those reject the whole request.
+func (inv *InverterLogger) SetReadGap(gap int) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.MaxReadGap = gap
+}
+
+// ReadMany reads scattered holding registers with the fewest requests
+// (see PlanReads) and returns the requested registers only
+func (inv *InverterLogger) ReadMany(registers []int) (map[int]uint16, error) {
+	return inv.ReadManyContext(context.Background(), registers)
+}
+
+func (inv *InverterLogger) ReadManyContext(ctx context.Context, registers []int) (map[int]uint16, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	res, err := inv.readMany(ctx, FuncReadHoldingRegisters, registers)
+	if err != nil {
+		return nil, inv.error("ReadMany.readMany", "request failed", err)
+	}
+
+	return res, nil
+}
+
+// readMany reads the planned ranges of holding or input registers, inv.mu must be held
+func (inv *InverterLogger) readMany(ctx context.Context, functionCode uint8, registers []int) (map[int]uint16, error) {
+	ranges := PlanReads(registers, inv.MaxReadGap, inv.maxReadRegisters())
+
+	all := make(map[int]uint16)
+
+	for i, r := range ranges {
+		if i > 0 && inv.ReadChunkDelay > 0 {
+			if err := sleepContext(ctx, inv.ReadChunkDelay); err != nil {
+				return nil, &ChunkError{Start: r.Start, Count: r.Count, Err: markTimeout(err)}
+			}
+		}
+
+		chunk, err := inv.readRegisters(ctx, functionCode, r.Start, r.Count)
+		if err != nil {
+			return nil, &ChunkError{Start: r.Start, Count: r.Count, Err: err}
+		}
+
+		for reg, val := range chunk {
+			all[reg] = val
+		}
+	}
+
+	res := make(map[int]uint16, len(registers))
+	for _, reg := range registers {
+		res[reg] = all[reg]
+	}
+
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package solarman
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanReads(t *testing.T) {
+	tests := []struct {
+		name      string
+		registers []int
+		maxGap    int
+		maxCount  int
+		want      []ReadRange
+	}{
+		{"empty", nil, 10, 0, nil},
+		{"single", []int{7}, 10, 0, []ReadRange{{7, 1}}},
+		{"adjacent", []int{1, 2, 3}, 0, 0, []ReadRange{{1, 3}}},
+		{"gap at limit", []int{0, 11}, 10, 0, []ReadRange{{0, 12}}},
+		{"gap over limit", []int{0, 12}, 10, 0, []ReadRange{{0, 1}, {12, 1}}},
+		{"zero gap", []int{0, 2}, 0, 0, []ReadRange{{0, 1}, {2, 1}}},
+		{"unsorted", []int{30, 5, 1, 3}, 2, 0, []ReadRange{{1, 5}, {30, 1}}},
+		{"duplicates", []int{4, 4, 5, 4, 5}, 0, 0, []ReadRange{{4, 2}}},
+		{"duplicate of range start", []int{10, 12, 10}, 1, 0, []ReadRange{{10, 3}}},
+		{"modbus limit", []int{0, 124}, 200, 0, []ReadRange{{0, 125}}},
+		{"over modbus limit", []int{0, 125}, 200, 0, []ReadRange{{0, 1}, {125, 1}}},
+		{"max count above modbus limit", []int{0, 125}, 200, 500, []ReadRange{{0, 1}, {125, 1}}},
+		{"max count", []int{0, 3, 4}, 10, 4, []ReadRange{{0, 4}, {4, 1}}},
+		{"max count one", []int{0, 1, 2}, 10, 1, []ReadRange{{0, 1}, {1, 1}, {2, 1}}},
+		{"split then merge", []int{0, 124, 125, 130}, 10, 0, []ReadRange{{0, 1}, {124, 7}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PlanReads(tt.registers, tt.maxGap, tt.maxCount)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// every requested register is covered and no range exceeds the limits
+func TestPlanReadsCoverage(t *testing.T) {
+	var registers []int
+	for reg := 0; reg < 1000; reg += 1 + reg%7 {
+		registers = append(registers, reg, reg)
+	}
+
+	for _, maxCount := range []int{1, 2, 10, 125} {
+		for _, maxGap := range []int{0, 3, 10, 200} {
+			ranges := PlanReads(registers, maxGap, maxCount)
+
+			covered := make(map[int]bool)
+			for i, r := range ranges {
+				if r.Count < 1 || r.Count > maxCount {
+					t.Fatalf("gap %d, count %d: range %v exceeds the limit", maxGap, maxCount, r)
+				}
+				if i > 0 && r.Start < ranges[i-1].Start+ranges[i-1].Count {
+					t.Fatalf("gap %d, count %d: range %v overlaps %v", maxGap, maxCount, r, ranges[i-1])
+				}
+				for reg := r.Start; reg < r.Start+r.Count; reg++ {
+					covered[reg] = true
+				}
+			}
+
+			for _, reg := range registers {
+				if !covered[reg] {
+					t.Fatalf("gap %d, count %d: register %d dropped", maxGap, maxCount, reg)
+				}
+			}
+		}
+	}
+}
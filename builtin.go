@@ -0,0 +1,139 @@
+package solarman
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// -----------------------------------------------------------------------------
+// Embedded inverter profiles
+// -----------------------------------------------------------------------------
+
+//go:embed profiles/*.yaml
+var builtinProfiles embed.FS
+
+// builtinFile is an embedded profile. A profile with a base starts from the
+// entries of the base profile, its own entries replace those of the same name.
+type builtinFile struct {
+	Base    string `yaml:"base"`
+	Profile `yaml:",inline"`
+}
+
+// BuiltinProfiles returns the names of the embedded profiles
+func BuiltinProfiles() []string {
+	entries, _ := builtinProfiles.ReadDir("profiles")
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// BuiltinProfile returns a fresh copy of an embedded profile, e.g. "deye_sg03lp1"
+func BuiltinProfile(name string) (*Profile, error) {
+	data, err := builtinProfiles.ReadFile("profiles/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown builtin profile %q, available: %s",
+			ErrInvalidProfile, name, strings.Join(BuiltinProfiles(), ", "))
+	}
+
+	var f builtinFile
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidProfile, name, err)
+	}
+
+	p := &f.Profile
+	if f.Base != "" {
+		base, err := BuiltinProfile(f.Base)
+		if err != nil {
+			return nil, err
+		}
+		if p.SlaveID == 0 {
+			p.SlaveID = base.SlaveID
+		}
+		p.Registers = overrideRegisters(base.Registers, p.Registers)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// overrideRegisters replaces the entries of base by the entries of the same name,
+// entries not found in base are appended
+func overrideRegisters(base []RegisterDef, overrides []RegisterDef) []RegisterDef {
+	index := make(map[string]int, len(base))
+	res := append([]RegisterDef(nil), base...)
+	for i, d := range res {
+		index[d.Name] = i
+	}
+
+	for _, d := range overrides {
+		if i, ok := index[d.Name]; ok {
+			res[i] = d
+			continue
+		}
+		res = append(res, d)
+	}
+
+	return res
+}
+
+// SetProfile sets the register map used by ReadValues
+//
+//	p, _ := solarman.BuiltinProfile("deye_sg03lp1")
+//	deye.SetProfile(p)
+//	values, err := deye.ReadValues("battery", "solar")
+func (inv *InverterLogger) SetProfile(p *Profile) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.Profile = p
+}
+
+// ReadValues reads the given groups of the profile set with SetProfile,
+// all entries if no group is given
+func (inv *InverterLogger) ReadValues(groups ...string) (map[string]Value, error) {
+	return inv.ReadValuesContext(context.Background(), groups...)
+}
+
+func (inv *InverterLogger) ReadValuesContext(ctx context.Context, groups ...string) (map[string]Value, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if inv.Profile == nil {
+		return nil, inv.error("ReadValues", "no profile", fmt.Errorf("%w: no profile set, see SetProfile", ErrInvalidProfile))
+	}
+
+	defs := inv.Profile.Registers
+	if len(groups) > 0 {
+		defs = nil
+		for _, group := range groups {
+			groupDefs := inv.Profile.Group(group)
+			if len(groupDefs) == 0 {
+				return nil, inv.error("ReadValues", "unknown group", fmt.Errorf("%w: no entries in group %q", ErrInvalidProfile, group))
+			}
+			defs = append(defs, groupDefs...)
+		}
+	}
+
+	res, err := inv.readDefs(ctx, inv.Profile.SlaveID, defs)
+	if err != nil {
+		return nil, inv.error("ReadValues.readDefs", "request failed", err)
+	}
+
+	return res, nil
+}
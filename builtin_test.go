@@ -0,0 +1,76 @@
+package solarman
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuiltinProfiles(t *testing.T) {
+	names := BuiltinProfiles()
+	if len(names) == 0 {
+		t.Fatal("no builtin profiles")
+	}
+
+	for _, name := range names {
+		p, err := BuiltinProfile(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if p.Name != name {
+			t.Errorf("%s: profile called %q", name, p.Name)
+		}
+	}
+
+	if _, err := BuiltinProfile("unknown"); err == nil {
+		t.Error("unknown profile loaded")
+	}
+}
+
+func TestBuiltinProfileBase(t *testing.T) {
+	lv, err := BuiltinProfile("deye_sg04lp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hv, err := BuiltinProfile("deye_sg01hp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hv.Registers) != len(lv.Registers) {
+		t.Fatalf("%d entries, base has %d", len(hv.Registers), len(lv.Registers))
+	}
+
+	overridden := map[string]bool{
+		"battery_voltage":       true,
+		"battery_power":         true,
+		"bms_charge_voltage":    true,
+		"bms_discharge_voltage": true,
+		"bms_voltage":           true,
+	}
+
+	for i, d := range hv.Registers {
+		base := lv.Registers[i]
+		switch {
+		case d.Name != base.Name:
+			t.Errorf("entry %d: %s in place of %s", i, d.Name, base.Name)
+		case overridden[d.Name] && d.Scale == base.Scale:
+			t.Errorf("%s: scale %v not overridden", d.Name, d.Scale)
+		case !overridden[d.Name] && !reflect.DeepEqual(d, base):
+			t.Errorf("%s: differs from base", d.Name)
+		}
+	}
+}
+
+func TestOverrideRegisters(t *testing.T) {
+	base := []RegisterDef{{Name: "a", Address: 1}, {Name: "b", Address: 2}}
+	got := overrideRegisters(base, []RegisterDef{{Name: "b", Address: 20}, {Name: "c", Address: 3}})
+
+	want := []RegisterDef{{Name: "a", Address: 1}, {Name: "b", Address: 20}, {Name: "c", Address: 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if base[1].Address != 2 {
+		t.Fatal("base modified")
+	}
+}
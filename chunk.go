@@ -0,0 +1,82 @@
+package solarman
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Chunked register reads
+// -----------------------------------------------------------------------------
+
+// MaxModbusRegisters is the Modbus limit for a single register read
+const MaxModbusRegisters = 125
+
+// ChunkError reports the request of a split read that failed
+type ChunkError struct {
+	Start int   // first register of the failed chunk
+	Count int   // number of registers in the failed chunk
+	Err   error // underlying cause
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("chunk 0x%X-0x%X (%d registers) failed: %v", e.Start, e.Start+e.Count-1, e.Count, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// SetReadChunking limits the number of registers per read request and sets
+// the pause between the requests of a split read. maxRegisters above
+// MaxModbusRegisters or not positive means MaxModbusRegisters.
+func (inv *InverterLogger) SetReadChunking(maxRegisters int, delay time.Duration) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.MaxReadRegisters = maxRegisters
+	inv.ReadChunkDelay = delay
+}
+
+func (inv *InverterLogger) maxReadRegisters() int {
+	if inv.MaxReadRegisters <= 0 || inv.MaxReadRegisters > MaxModbusRegisters {
+		return MaxModbusRegisters
+	}
+	return inv.MaxReadRegisters
+}
+
+// readChunked reads regCnt registers in requests of at most inv.maxReadRegisters()
+// and merges the results, inv.mu must be held
+func (inv *InverterLogger) readChunked(ctx context.Context, functionCode uint8, startReg, regCnt int) (map[int]uint16, error) {
+	chunkSize := inv.maxReadRegisters()
+	if regCnt <= chunkSize {
+		return inv.readRegisters(ctx, functionCode, startReg, regCnt)
+	}
+
+	res := make(map[int]uint16, regCnt)
+
+	for offset := 0; offset < regCnt; offset += chunkSize {
+		count := regCnt - offset
+		if count > chunkSize {
+			count = chunkSize
+		}
+
+		if offset > 0 && inv.ReadChunkDelay > 0 {
+			if err := sleepContext(ctx, inv.ReadChunkDelay); err != nil {
+				return nil, &ChunkError{Start: startReg + offset, Count: count, Err: markTimeout(err)}
+			}
+		}
+
+		chunk, err := inv.readRegisters(ctx, functionCode, startReg+offset, count)
+		if err != nil {
+			return nil, &ChunkError{Start: startReg + offset, Count: count, Err: err}
+		}
+
+		for reg, val := range chunk {
+			res[reg] = val
+		}
+	}
+
+	return res, nil
+}
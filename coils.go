@@ -0,0 +1,252 @@
+package solarman
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+)
+
+// -----------------------------------------------------------------------------
+// Coils and discrete inputs (Modbus functions 0x01, 0x02, 0x05, 0x0F)
+// -----------------------------------------------------------------------------
+
+const (
+	coilOn  uint16 = 0xFF00
+	coilOff uint16 = 0x0000
+)
+
+// Modbus limits for a single request
+const (
+	MaxModbusReadBits   = 2000 // coils or discrete inputs per read (0x01, 0x02)
+	MaxModbusWriteCoils = 1968 // coils per write (0x0F)
+)
+
+type WriteCoilsRequestPayload struct {
+	FrameType    uint8
+	SensorType   uint16
+	DeliveryTime uint32
+	PowerOnTime  uint32
+	OffsetTime   uint32
+
+	DeviceAddress uint8  // Modbus slave address, InverterLogger.SlaveID
+	FunctionCode  uint8  // Function code for writing multiple coils = 0x0F
+	StartAddress  uint16 // Address of the first coil
+	Values        []bool // Coil states, one per coil
+}
+
+func (inv *InverterLogger) NewWriteCoilsRequestPayload(startAddress uint16, values []bool) *WriteCoilsRequestPayload {
+	return &WriteCoilsRequestPayload{
+		FrameType:     0x02,
+		SensorType:    0x0000,
+		DeliveryTime:  0x00000000,
+		PowerOnTime:   0x00000000,
+		OffsetTime:    0x00000000,
+		DeviceAddress: inv.SlaveID,
+		FunctionCode:  FuncWriteMultipleCoils,
+		StartAddress:  startAddress,
+		Values:        values,
+	}
+}
+
+func (w *WriteCoilsRequestPayload) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := marshalRequestHeader(&buf, w.FrameType, w.SensorType, w.DeliveryTime, w.PowerOnTime, w.OffsetTime); err != nil {
+		return nil, err
+	}
+
+	// 1 byte: DeviceAddress
+	// 1 byte: FunctionCode (0x0F)
+	// 2 bytes: Starting address (Big Endian)
+	// 2 bytes: Quantity of coils (Big Endian)
+	// 1 byte: Byte Count
+	// N bytes: Coil states, lowest address in the least significant bit
+	// 2 bytes: CRC16 (Little Endian)
+	if len(w.Values) < 1 || len(w.Values) > MaxModbusWriteCoils {
+		return nil, fmt.Errorf("%d coils out of range 1..%d", len(w.Values), MaxModbusWriteCoils)
+	}
+
+	packed := packBits(w.Values)
+
+	modbus := []byte{w.DeviceAddress, w.FunctionCode}
+	modbus = append(modbus, byte(w.StartAddress>>8), byte(w.StartAddress))
+	modbus = append(modbus, byte(len(w.Values)>>8), byte(len(w.Values)))
+	modbus = append(modbus, byte(len(packed)))
+	modbus = append(modbus, packed...)
+
+	crc := calcCRC16Modbus(modbus)
+	modbus = append(modbus, byte(crc), byte(crc>>8))
+
+	buf.Write(modbus)
+
+	return buf.Bytes(), nil
+}
+
+func packBits(values []bool) []byte {
+	packed := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
+
+/* Public methods */
+
+// ReadCoils reads count coils starting from startAddress (Modbus function 0x01)
+func (inv *InverterLogger) ReadCoils(startAddress, count int) (map[int]bool, error) {
+	return inv.ReadCoilsContext(context.Background(), startAddress, count)
+}
+
+func (inv *InverterLogger) ReadCoilsContext(ctx context.Context, startAddress, count int) (map[int]bool, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	res, err := inv.readBits(ctx, FuncReadCoils, startAddress, count)
+	if err != nil {
+		return nil, inv.error("ReadCoils.readBits", "request failed", err)
+	}
+
+	return res, nil
+}
+
+// ReadDiscreteInputs reads count discrete inputs starting from startAddress (Modbus function 0x02)
+func (inv *InverterLogger) ReadDiscreteInputs(startAddress, count int) (map[int]bool, error) {
+	return inv.ReadDiscreteInputsContext(context.Background(), startAddress, count)
+}
+
+func (inv *InverterLogger) ReadDiscreteInputsContext(ctx context.Context, startAddress, count int) (map[int]bool, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	res, err := inv.readBits(ctx, FuncReadDiscreteInputs, startAddress, count)
+	if err != nil {
+		return nil, inv.error("ReadDiscreteInputs.readBits", "request failed", err)
+	}
+
+	return res, nil
+}
+
+// WriteCoil switches a single coil (Modbus function 0x05)
+func (inv *InverterLogger) WriteCoil(address int, value bool) error {
+	return inv.WriteCoilContext(context.Background(), address, value)
+}
+
+func (inv *InverterLogger) WriteCoilContext(ctx context.Context, address int, value bool) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if err := inv.WritePolicy.checkCoils(address, []bool{value}); err != nil {
+		return inv.error("WriteCoil.checkCoils", "write denied", err)
+	}
+
+	state := coilOff
+	if value {
+		state = coilOn
+	}
+
+	// same layout as Write Single Register: address and value words
+	request := inv.NewWriteSingleRequestPayload(uint16(address), state)
+	request.FunctionCode = FuncWriteSingleCoil
+	request.DeviceAddress = inv.slaveID(ctx)
+
+	writePayload, err := request.MarshalBinary()
+	if err != nil {
+		return inv.error("WriteCoil.writePayload", "payload marshal failed", err)
+	}
+
+	err = inv.writeEcho(ctx, writePayload, request.DeviceAddress, request.FunctionCode, request.RegisterAddress, request.RegisterValue)
+	if err != nil {
+		return inv.error("WriteCoil.writeEcho", "request failed", err)
+	}
+
+	return nil
+}
+
+// WriteCoils switches consecutive coils starting from startAddress (Modbus function 0x0F)
+func (inv *InverterLogger) WriteCoils(startAddress int, values []bool) error {
+	return inv.WriteCoilsContext(context.Background(), startAddress, values)
+}
+
+func (inv *InverterLogger) WriteCoilsContext(ctx context.Context, startAddress int, values []bool) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if err := inv.WritePolicy.checkCoils(startAddress, values); err != nil {
+		return inv.error("WriteCoils.checkCoils", "write denied", err)
+	}
+
+	request := inv.NewWriteCoilsRequestPayload(uint16(startAddress), values)
+	request.DeviceAddress = inv.slaveID(ctx)
+
+	writePayload, err := request.MarshalBinary()
+	if err != nil {
+		return inv.error("WriteCoils.writePayload", "payload marshal failed", err)
+	}
+
+	// the reply echoes start address and quantity
+	err = inv.writeEcho(ctx, writePayload, request.DeviceAddress, request.FunctionCode, request.StartAddress, uint16(len(values)))
+	if err != nil {
+		return inv.error("WriteCoils.writeEcho", "request failed", err)
+	}
+
+	return nil
+}
+
+/* private methods */
+
+// readBits reads coils (0x01) or discrete inputs (0x02), inv.mu must be held
+func (inv *InverterLogger) readBits(ctx context.Context, functionCode uint8, startAddress, count int) (map[int]bool, error) {
+	if count < 1 || count > MaxModbusReadBits {
+		return nil, inv.error("readBits", "invalid count", fmt.Errorf("%d bits out of range 1..%d", count, MaxModbusReadBits))
+	}
+
+	// request layout is the same as for registers: start address and quantity
+	request := inv.NewReadRequestPayload(uint16(startAddress), uint16(count))
+	request.FunctionCode = functionCode
+	request.DeviceAddress = inv.slaveID(ctx)
+
+	requestPayload, err := request.MarshalBinary(inv)
+	if err != nil {
+		return nil, inv.error("readBits.requestPayload", "payload marshal failed", err)
+	}
+
+	res := make(map[int]bool)
+
+	err = inv.exchange(ctx, requestPayload, false, func(responseFrame *Frame) error {
+		var responsePayload ResponsePayload
+		modbus, err := inv.unmarshalHeader(&responsePayload, responseFrame.Payload)
+		if err != nil {
+			return inv.frameError("readBits.responsePayload.unmarshalHeader", "payload unmarshal failed", responseFrame.raw, err)
+		}
+
+		if err := responsePayload.unmarshalModbus(inv, modbus); err != nil {
+			return inv.frameError("readBits.responsePayload.UnmarshalBinary", "payload unmarshal failed", responseFrame.raw, err)
+		}
+
+		if err := responsePayload.answers(request.DeviceAddress, functionCode); err != nil {
+			return inv.frameError("readBits.responsePayload.answers", "unexpected response", responseFrame.raw, err)
+		}
+
+		if len(responsePayload.Value) < (count+7)/8 {
+			return inv.frameError("readBits.responsePayload.Value", "read payload failed", responseFrame.raw,
+				fmt.Errorf("%w: %d bytes for %d bits", ErrShortFrame, len(responsePayload.Value), count))
+		}
+
+		inv.debug("readBits.responsePayload.Value", "bits received",
+			"function", functionCode, "start", startAddress, "count", count, "hex", hex.EncodeToString(responsePayload.Value))
+
+		for i := 0; i < count; i++ {
+			res[startAddress+i] = responsePayload.Value[i/8]&(1<<(i%8)) != 0
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, inv.error("readBits.exchange", "request failed", err)
+	}
+
+	return res, nil
+}
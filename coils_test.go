@@ -0,0 +1,47 @@
+package solarman
+
+import "testing"
+
+func TestCoilLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		read  bool // ReadCoils and ReadDiscreteInputs, WriteCoils otherwise
+		ok    bool
+	}{
+		{"read none", 0, true, false},
+		{"read one", 1, true, true},
+		{"read limit", MaxModbusReadBits, true, true},
+		{"read over limit", MaxModbusReadBits + 1, true, false},
+		{"write none", 0, false, false},
+		{"write one", 1, false, true},
+		{"write limit", MaxModbusWriteCoils, false, true},
+		{"write over limit", MaxModbusWriteCoils + 1, false, false},
+		{"write over byte count", 2041, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, fake := newFakeInverter(t)
+
+			var errs []error
+			if tt.read {
+				_, err := inv.ReadCoils(0, tt.count)
+				errs = append(errs, err)
+				_, err = inv.ReadDiscreteInputs(0, tt.count)
+				errs = append(errs, err)
+			} else {
+				errs = append(errs, inv.WriteCoils(0, make([]bool, tt.count)))
+			}
+
+			for _, err := range errs {
+				if (err == nil) != tt.ok {
+					t.Fatalf("count %d: got %v", tt.count, err)
+				}
+			}
+			if !tt.ok && len(fake.functions()) != 0 {
+				t.Fatalf("count %d: request sent", tt.count)
+			}
+		})
+	}
+}
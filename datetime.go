@@ -1,6 +1,7 @@
 package solarman
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -55,17 +56,21 @@ func (inv *InverterLogger) bytesToLocalTime(regs []int) (time.Time, error) {
 /* Public methods */
 
 func (inv *InverterLogger) GetDateTime(startRegister int) (time.Time, error) {
+	return inv.GetDateTimeContext(context.Background(), startRegister)
+}
 
-	registers, err := inv.Read(startRegister, 3)
+func (inv *InverterLogger) GetDateTimeContext(ctx context.Context, startRegister int) (time.Time, error) {
 
-	if len(registers) < 3 {
-		return time.Time{}, fmt.Errorf("GetDateTime: expected 3 registers, got %d", len(registers))
-	}
+	registers, err := inv.ReadContext(ctx, startRegister, 3)
 
 	if err != nil {
 		return time.Time{}, err
 	}
 
+	if len(registers) < 3 {
+		return time.Time{}, fmt.Errorf("GetDateTime: expected 3 registers, got %d", len(registers))
+	}
+
 	yymm := registers[startRegister]
 	hhmm := registers[startRegister+1]
 	mmss := registers[startRegister+2]
@@ -81,10 +86,20 @@ func (inv *InverterLogger) GetDateTime(startRegister int) (time.Time, error) {
 }
 
 func (inv *InverterLogger) SetDateTime(startRegister int, setTime time.Time) (int, int, time.Time, error) {
+	return inv.SetDateTimeContext(context.Background(), startRegister, setTime)
+}
+
+// SetDateTimeContext writes the inverter clock, with write verification enabled
+// the clock is read back and compared within DateTimeVerifyTolerance
+func (inv *InverterLogger) SetDateTimeContext(ctx context.Context, startRegister int, setTime time.Time) (int, int, time.Time, error) {
 
 	inverterTime := inv.localTimeToBytes(setTime)
 
-	cnt, start, err := inv.Write(startRegister, inverterTime)
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	cnt, start, err := inv.writeRegisters(ctx, startRegister, inverterTime)
+	writtenAt := time.Now()
 
 	if err != nil {
 		return 0, 0, time.Time{}, err
@@ -100,5 +115,11 @@ func (inv *InverterLogger) SetDateTime(startRegister int, setTime time.Time) (in
 		return 0, 0, time.Time{}, err
 	}
 
+	if inv.VerifyWrites && !inv.WritePolicy.DryRun {
+		if err := inv.verifyDateTime(ctx, startRegister, timeSet, writtenAt); err != nil {
+			return 0, 0, time.Time{}, inv.error("SetDateTime.verifyDateTime", "verification failed", err)
+		}
+	}
+
 	return cnt, start, timeSet, nil
 }
@@ -0,0 +1,234 @@
+package solarman
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Typed register decoders
+// -----------------------------------------------------------------------------
+
+// WordOrder is the order of registers holding a multi-register value
+type WordOrder string
+
+const (
+	LowWordFirst  WordOrder = "low_first"  // least significant register at the lower address (Deye)
+	HighWordFirst WordOrder = "high_first" // most significant register at the lower address (Sofar, Solis)
+)
+
+// ByteOrder is the order of the two bytes inside a register
+type ByteOrder string
+
+const (
+	HighByteFirst ByteOrder = "high_first" // Modbus standard
+	LowByteFirst  ByteOrder = "low_first"  // byte swapped devices
+)
+
+// Registers decodes typed values from a Read result:
+//
+//	data, err := deye.Read(0x03, 5)
+//	serial, err := solarman.Registers(data).ASCII(0x03, 5, solarman.HighByteFirst)
+//
+// Every method fails with ErrMissingRegister if a register was not read.
+type Registers map[int]uint16
+
+// Words returns n registers starting from address in address order
+func (r Registers) Words(address, n int) ([]uint16, error) {
+	words := make([]uint16, n)
+	for i := range words {
+		v, ok := r[address+i]
+		if !ok {
+			return nil, fmt.Errorf("%w: 0x%X", ErrMissingRegister, address+i)
+		}
+		words[i] = v
+	}
+	return words, nil
+}
+
+func (r Registers) U16(address int) (uint16, error) {
+	words, err := r.Words(address, 1)
+	if err != nil {
+		return 0, err
+	}
+	return words[0], nil
+}
+
+func (r Registers) I16(address int) (int16, error) {
+	v, err := r.U16(address)
+	return int16(v), err
+}
+
+func (r Registers) U32(address int, order WordOrder) (uint32, error) {
+	v, err := r.Uint(address, 2, order, HighByteFirst)
+	return uint32(v), err
+}
+
+func (r Registers) I32(address int, order WordOrder) (int32, error) {
+	v, err := r.Uint(address, 2, order, HighByteFirst)
+	return int32(uint32(v)), err
+}
+
+func (r Registers) U64(address int, order WordOrder) (uint64, error) {
+	return r.Uint(address, 4, order, HighByteFirst)
+}
+
+func (r Registers) I64(address int, order WordOrder) (int64, error) {
+	v, err := r.Uint(address, 4, order, HighByteFirst)
+	return int64(v), err
+}
+
+// Uint decodes an unsigned value of n registers (1 to 4) with any word and byte order
+func (r Registers) Uint(address, n int, words WordOrder, bytes ByteOrder) (uint64, error) {
+	if n < 1 || n > 4 {
+		return 0, fmt.Errorf("%d registers do not fit into 64 bits", n)
+	}
+	raw, err := r.Words(address, n)
+	if err != nil {
+		return 0, err
+	}
+	return decodeUint(raw, words, bytes), nil
+}
+
+// ASCII decodes a string of two characters per register,
+// trailing NUL bytes and spaces are dropped
+func (r Registers) ASCII(address, n int, bytes ByteOrder) (string, error) {
+	raw, err := r.Words(address, n)
+	if err != nil {
+		return "", err
+	}
+	return decodeASCII(raw, bytes), nil
+}
+
+// BCD decodes packed decimal digits, four per register in address order
+func (r Registers) BCD(address, n int) (string, error) {
+	raw, err := r.Words(address, n)
+	if err != nil {
+		return "", err
+	}
+	return decodeBCD(raw)
+}
+
+// Bit reports whether bit n (0 is the least significant) of a register is set
+func (r Registers) Bit(address int, n uint) (bool, error) {
+	if n > 15 {
+		return false, fmt.Errorf("bit %d does not fit into 16 bits", n)
+	}
+	v, err := r.U16(address)
+	if err != nil {
+		return false, err
+	}
+	return v&(1<<n) != 0, nil
+}
+
+// Field extracts the bits of mask shifted down to bit 0,
+// e.g. mask 0xFF00 returns the high byte
+func (r Registers) Field(address int, mask uint16) (uint16, error) {
+	v, err := r.U16(address)
+	if err != nil {
+		return 0, err
+	}
+	return extractField(v, mask), nil
+}
+
+// Enum returns the label of a register value, "unknown (n)" for values without label
+func (r Registers) Enum(address int, labels map[int]string) (string, error) {
+	v, err := r.U16(address)
+	if err != nil {
+		return "", err
+	}
+	return enumLabel(labels, int(v)), nil
+}
+
+// Scaled applies fixed-point scaling, (raw - offset) * scale, rounded to the
+// decimals of scale and offset: 2301 * 0.1 gives 230.1, 3 * 0.25 gives 0.75
+func Scaled(raw float64, scale float64, offset float64) float64 {
+	return roundScaled((raw-offset)*scale, decimals(scale)+decimals(offset))
+}
+
+/* private functions */
+
+// decodeUint joins registers into one value
+func decodeUint(raw []uint16, words WordOrder, bytes ByteOrder) uint64 {
+	var v uint64
+	for i := range raw {
+		// most significant register first
+		word := raw[i]
+		if words != HighWordFirst {
+			word = raw[len(raw)-1-i]
+		}
+		if bytes == LowByteFirst {
+			word = bits.ReverseBytes16(word)
+		}
+		v = v<<16 | uint64(word)
+	}
+	return v
+}
+
+// signExtend interprets the lowest n registers of v as a signed value
+func signExtend(v uint64, n int) int64 {
+	shift := uint(64 - 16*n)
+	return int64(v<<shift) >> shift
+}
+
+func decodeASCII(raw []uint16, bytes ByteOrder) string {
+	b := make([]byte, 0, len(raw)*2)
+	for _, word := range raw {
+		if bytes == LowByteFirst {
+			word = bits.ReverseBytes16(word)
+		}
+		b = append(b, byte(word>>8), byte(word))
+	}
+	return strings.TrimRight(string(b), "\x00 ")
+}
+
+func decodeBCD(raw []uint16) (string, error) {
+	var b strings.Builder
+	for _, word := range raw {
+		for shift := 12; shift >= 0; shift -= 4 {
+			digit := word >> shift & 0xF
+			if digit > 9 {
+				return "", fmt.Errorf("invalid BCD digit 0x%X in 0x%04X", digit, word)
+			}
+			b.WriteByte('0' + byte(digit))
+		}
+	}
+	return b.String(), nil
+}
+
+func extractField(v uint16, mask uint16) uint16 {
+	if mask == 0 {
+		return v
+	}
+	return v & mask >> bits.TrailingZeros16(mask)
+}
+
+func enumLabel(labels map[int]string, key int) string {
+	if label, ok := labels[key]; ok {
+		return label
+	}
+	return fmt.Sprintf("unknown (%d)", key)
+}
+
+// roundScaled drops the float noise of scaling, 2301 * 0.1 gives 230.1
+// instead of 230.10000000000002. An integer raw value times scale has no more
+// decimals than scale, digits beyond float64 precision are left as is.
+func roundScaled(v float64, digits int) float64 {
+	if digits <= 0 || digits > 15 {
+		return v
+	}
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
+
+// decimals counts the digits after the decimal point of the shortest representation of f
+func decimals(f float64) int {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
@@ -0,0 +1,217 @@
+package solarman
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScaled(t *testing.T) {
+	tests := []struct {
+		raw, scale, offset float64
+		want               float64
+	}{
+		{2301, 0.1, 0, 230.1},
+		{3, 0.1, 0, 0.3},
+		{-15, 0.1, 0, -1.5},
+		{4999, 0.01, 0, 49.99},
+		{7, 0.01, 0, 0.07},
+		{1, 0.25, 0, 0.25},
+		{3, 0.25, 0, 0.75},
+		{-3, 0.25, 0, -0.75},
+		{123, 10, 0, 1230},
+		{1234, 1, 1000, 234},
+		{1100, 0.1, 1000, 10},
+		{3, 0.5, 0.5, 1.25},
+		{1, 1.0 / 3, 0, 1.0 / 3},
+	}
+
+	for _, tt := range tests {
+		if got := Scaled(tt.raw, tt.scale, tt.offset); got != tt.want {
+			t.Errorf("Scaled(%v, %v, %v) = %v, want %v", tt.raw, tt.scale, tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeUint(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   []uint16
+		words WordOrder
+		bytes ByteOrder
+		want  uint64
+	}{
+		{"single", []uint16{0x1234}, LowWordFirst, HighByteFirst, 0x1234},
+		{"single byte swapped", []uint16{0x1234}, LowWordFirst, LowByteFirst, 0x3412},
+		{"low word first", []uint16{0x5678, 0x1234}, LowWordFirst, HighByteFirst, 0x12345678},
+		{"high word first", []uint16{0x1234, 0x5678}, HighWordFirst, HighByteFirst, 0x12345678},
+		{"high word first byte swapped", []uint16{0x3412, 0x7856}, HighWordFirst, LowByteFirst, 0x12345678},
+		{"empty word order", []uint16{0x5678, 0x1234}, "", HighByteFirst, 0x12345678},
+		{"64 bit low word first", []uint16{0x7788, 0x5566, 0x3344, 0x1122}, LowWordFirst, HighByteFirst, 0x1122334455667788},
+		{"64 bit high word first", []uint16{0x1122, 0x3344, 0x5566, 0x7788}, HighWordFirst, HighByteFirst, 0x1122334455667788},
+	}
+
+	for _, tt := range tests {
+		if got := decodeUint(tt.raw, tt.words, tt.bytes); got != tt.want {
+			t.Errorf("%s: got 0x%X, want 0x%X", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSignExtend(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		n    int
+		want int64
+	}{
+		{0x0001, 1, 1},
+		{0x7FFF, 1, 32767},
+		{0x8000, 1, -32768},
+		{0xFFFF, 1, -1},
+		{0x1234FFFF, 1, -1}, // bits above n registers are ignored
+		{0x0000FFFF, 2, 65535},
+		{0xFFFFFFFF, 2, -1},
+		{0x80000000, 2, -2147483648},
+		{0x7FFFFFFFFFFFFFFF, 4, 9223372036854775807},
+		{0xFFFFFFFFFFFFFFFE, 4, -2},
+	}
+
+	for _, tt := range tests {
+		if got := signExtend(tt.v, tt.n); got != tt.want {
+			t.Errorf("signExtend(0x%X, %d) = %d, want %d", tt.v, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeASCII(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   []uint16
+		bytes ByteOrder
+		want  string
+	}{
+		{"plain", []uint16{0x4142, 0x4344}, HighByteFirst, "ABCD"},
+		{"byte swapped", []uint16{0x4241, 0x4443}, LowByteFirst, "ABCD"},
+		{"trailing NUL", []uint16{0x4142, 0x4300, 0x0000}, HighByteFirst, "ABC"},
+		{"trailing spaces", []uint16{0x4142, 0x2020}, HighByteFirst, "AB"},
+		{"inner space kept", []uint16{0x4120, 0x4200}, HighByteFirst, "A B"},
+		{"leading NUL kept", []uint16{0x0041}, HighByteFirst, "\x00A"},
+		{"empty", []uint16{0x0000}, HighByteFirst, ""},
+	}
+
+	for _, tt := range tests {
+		if got := decodeASCII(tt.raw, tt.bytes); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeBCD(t *testing.T) {
+	tests := []struct {
+		raw     []uint16
+		want    string
+		wantErr bool
+	}{
+		{[]uint16{0x2024}, "2024", false},
+		{[]uint16{0x0012, 0x3456}, "00123456", false},
+		{[]uint16{0x202A}, "", true},
+		{[]uint16{0x1234, 0xF000}, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := decodeBCD(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("decodeBCD(% X) = %q, %v", tt.raw, got, err)
+		}
+	}
+}
+
+func TestExtractField(t *testing.T) {
+	tests := []struct {
+		v, mask uint16
+		want    uint16
+	}{
+		{0xABCD, 0, 0xABCD},
+		{0xABCD, 0xFFFF, 0xABCD},
+		{0xABCD, 0x00FF, 0xCD},
+		{0xABCD, 0xFF00, 0xAB},
+		{0xABCD, 0x0F00, 0xB},
+		{0x0010, 0x0010, 1},
+		{0x0020, 0x0010, 0},
+		{0x8000, 0x8000, 1},
+	}
+
+	for _, tt := range tests {
+		if got := extractField(tt.v, tt.mask); got != tt.want {
+			t.Errorf("extractField(0x%04X, 0x%04X) = 0x%X, want 0x%X", tt.v, tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestRegisters(t *testing.T) {
+	r := Registers{
+		0x10: 0xFFFE,
+		0x11: 0xFFFF,
+		0x12: 0x4142,
+		0x13: 0x4300,
+		0x14: 0x2024,
+		0x15: 2,
+	}
+
+	if v, err := r.I16(0x10); err != nil || v != -2 {
+		t.Errorf("I16 = %d, %v", v, err)
+	}
+	if v, err := r.I32(0x10, LowWordFirst); err != nil || v != -2 {
+		t.Errorf("I32 = %d, %v", v, err)
+	}
+	if v, err := r.U32(0x10, HighWordFirst); err != nil || v != 0xFFFEFFFF {
+		t.Errorf("U32 = 0x%X, %v", v, err)
+	}
+	if v, err := r.ASCII(0x12, 2, HighByteFirst); err != nil || v != "ABC" {
+		t.Errorf("ASCII = %q, %v", v, err)
+	}
+	if v, err := r.BCD(0x14, 1); err != nil || v != "2024" {
+		t.Errorf("BCD = %q, %v", v, err)
+	}
+	if v, err := r.Bit(0x15, 1); err != nil || !v {
+		t.Errorf("Bit = %v, %v", v, err)
+	}
+	if v, err := r.Bit(0x10, 15); err != nil || !v {
+		t.Errorf("Bit 15 = %v, %v", v, err)
+	}
+	if _, err := r.Bit(0x10, 16); err == nil {
+		t.Error("Bit 16 succeeded")
+	}
+	if v, err := r.Field(0x10, 0x00F0); err != nil || v != 0xF {
+		t.Errorf("Field = 0x%X, %v", v, err)
+	}
+	if v, err := r.Enum(0x15, map[int]string{2: "on-grid"}); err != nil || v != "on-grid" {
+		t.Errorf("Enum = %q, %v", v, err)
+	}
+	if v, err := r.Enum(0x14, map[int]string{2: "on-grid"}); err != nil || v != "unknown (8228)" {
+		t.Errorf("Enum = %q, %v", v, err)
+	}
+	if _, err := r.Uint(0x10, 5, LowWordFirst, HighByteFirst); err == nil {
+		t.Error("Uint of 5 registers succeeded")
+	}
+}
+
+func TestRegistersMissing(t *testing.T) {
+	r := Registers{0x10: 1, 0x12: 3}
+
+	calls := map[string]func() error{
+		"U16":   func() error { _, err := r.U16(0x11); return err },
+		"U32":   func() error { _, err := r.U32(0x10, LowWordFirst); return err },
+		"I64":   func() error { _, err := r.I64(0x10, LowWordFirst); return err },
+		"ASCII": func() error { _, err := r.ASCII(0x10, 3, HighByteFirst); return err },
+		"BCD":   func() error { _, err := r.BCD(0x12, 2); return err },
+		"Bit":   func() error { _, err := r.Bit(0x13, 0); return err },
+		"Field": func() error { _, err := r.Field(0x11, 0xFF); return err },
+		"Enum":  func() error { _, err := r.Enum(0x11, nil); return err },
+	}
+
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrMissingRegister) {
+			t.Errorf("%s: got %v, want ErrMissingRegister", name, err)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package solarman
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Annotated V5 frame dissector
+// -----------------------------------------------------------------------------
+
+// Field is one decoded element of a V5 frame
+type Field struct {
+	Name   string // field name, e.g. "control code"
+	Offset int    // offset from the start of the frame
+	Raw    []byte // bytes of the field
+	Value  string // human readable value
+	Valid  bool   // false if the field is malformed, truncated or fails its check
+	Note   string // explanation for invalid fields or extra information
+}
+
+// Dissection is a field-by-field breakdown of a raw V5 frame
+type Dissection struct {
+	Fields []Field
+	Valid  bool // true if every field is valid
+}
+
+func (d *Dissection) String() string {
+	var b strings.Builder
+
+	for _, f := range d.Fields {
+		mark := " "
+		if !f.Valid {
+			mark = "!"
+		}
+		fmt.Fprintf(&b, "%s %04d %-24s %-20s %s", mark, f.Offset, hex.EncodeToString(f.Raw), f.Name, f.Value)
+		if f.Note != "" {
+			fmt.Fprintf(&b, " (%s)", f.Note)
+		}
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// Decode breaks a raw V5 frame into annotated fields using DefaultMeta.
+// It never fails: problems are reported through the Valid flags.
+func Decode(data []byte) *Dissection {
+	return DecodeMeta(data, DefaultMeta)
+}
+
+// DecodeMeta is Decode for loggers with non-default markers and control codes
+func DecodeMeta(data []byte, meta FrameMeta) *Dissection {
+	s := &dissector{data: data, d: &Dissection{Valid: true}}
+	s.frame(meta)
+	return s.d
+}
+
+type dissector struct {
+	data []byte
+	pos  int
+	d    *Dissection
+}
+
+func (s *dissector) add(f Field) {
+	if !f.Valid {
+		s.d.Valid = false
+	}
+	s.d.Fields = append(s.d.Fields, f)
+}
+
+// take consumes n bytes as a field, an invalid "truncated" field is added if
+// fewer bytes are left within limit
+func (s *dissector) take(name string, n int, limit int) ([]byte, bool) {
+	if s.pos+n > limit {
+		s.add(Field{Name: name, Offset: s.pos, Raw: s.data[s.pos:limit], Note: fmt.Sprintf("truncated, %d of %d bytes", limit-s.pos, n)})
+		s.pos = limit
+		return nil, false
+	}
+
+	raw := s.data[s.pos : s.pos+n]
+	s.d.Fields = append(s.d.Fields, Field{Name: name, Offset: s.pos, Raw: raw, Valid: true})
+	s.pos += n
+
+	return raw, true
+}
+
+// set fills value and validity of the last taken field
+func (s *dissector) set(value string, valid bool, note string) {
+	f := &s.d.Fields[len(s.d.Fields)-1]
+	f.Value = value
+	f.Valid = valid
+	f.Note = note
+	if !valid {
+		s.d.Valid = false
+	}
+}
+
+func (s *dissector) frame(meta FrameMeta) {
+	end := len(s.data)
+
+	if b, ok := s.take("start marker", 1, end); ok {
+		s.set(fmt.Sprintf("0x%02X", b[0]), b[0] == meta.StartMarker, expected(b[0] == meta.StartMarker, "0x%02X", meta.StartMarker))
+	}
+
+	payloadLength := 0
+	if b, ok := s.take("length", 2, end); ok {
+		payloadLength = int(binary.LittleEndian.Uint16(b))
+		s.set(fmt.Sprintf("%d", payloadLength), true, "")
+	}
+
+	isRequest := false
+	if b, ok := s.take("control code", 2, end); ok {
+		code := binary.LittleEndian.Uint16(b)
+		switch code {
+		case meta.ReqControlCode:
+			isRequest = true
+			s.set(fmt.Sprintf("0x%04X request", code), true, "")
+		case meta.ResControlCode:
+			s.set(fmt.Sprintf("0x%04X response", code), true, "")
+		default:
+			s.set(fmt.Sprintf("0x%04X", code), false, "unknown control code")
+		}
+	}
+
+	if b, ok := s.take("sequence", 2, end); ok {
+		if isRequest {
+			s.set(fmt.Sprintf("0x%04X", binary.LittleEndian.Uint16(b)), true, "")
+		} else {
+			s.set(fmt.Sprintf("request 0x%02X, logger 0x%02X", b[0], b[1]), true, "")
+		}
+	}
+
+	if b, ok := s.take("logger SN", 4, end); ok {
+		s.set(fmt.Sprintf("%d", binary.LittleEndian.Uint32(b)), true, "")
+	}
+
+	if s.pos < frameHeaderLen {
+		return
+	}
+
+	payloadEnd := frameHeaderLen + payloadLength
+	if payloadEnd > end {
+		payloadEnd = end
+	}
+
+	if isRequest {
+		s.requestHeader(payloadEnd)
+	} else {
+		s.responseHeader(payloadEnd)
+	}
+	s.modbus(payloadEnd, isRequest)
+
+	if payloadEnd < frameHeaderLen+payloadLength {
+		s.add(Field{Name: "payload", Offset: payloadEnd, Note: fmt.Sprintf("truncated, %d of %d bytes", payloadEnd-frameHeaderLen, payloadLength)})
+		return
+	}
+
+	if b, ok := s.take("checksum", 1, end); ok {
+		checksum := calcCheckSum8(s.data[1 : s.pos-1])
+		s.set(fmt.Sprintf("0x%02X", b[0]), b[0] == checksum, expected(b[0] == checksum, "0x%02X", checksum))
+	}
+
+	if b, ok := s.take("end marker", 1, end); ok {
+		s.set(fmt.Sprintf("0x%02X", b[0]), b[0] == meta.EndMarker, expected(b[0] == meta.EndMarker, "0x%02X", meta.EndMarker))
+	}
+
+	if s.pos < end {
+		s.add(Field{Name: "trailing bytes", Offset: s.pos, Raw: s.data[s.pos:], Note: "bytes after end marker"})
+	}
+}
+
+func (s *dissector) requestHeader(limit int) {
+	if b, ok := s.take("frame type", 1, limit); ok {
+		s.set(fmt.Sprintf("0x%02X", b[0]), true, "")
+	}
+	if b, ok := s.take("sensor type", 2, limit); ok {
+		s.set(fmt.Sprintf("0x%04X", binary.LittleEndian.Uint16(b)), true, "")
+	}
+	s.timeFields(limit)
+}
+
+func (s *dissector) responseHeader(limit int) {
+	if b, ok := s.take("frame type", 1, limit); ok {
+		s.set(fmt.Sprintf("0x%02X", b[0]), true, "")
+	}
+	if b, ok := s.take("status", 1, limit); ok {
+		s.set(LoggerStatus(b[0]).String(), true, "")
+	}
+	s.timeFields(limit)
+}
+
+func (s *dissector) timeFields(limit int) {
+	for _, name := range []string{"delivery time", "power on time", "offset time"} {
+		if b, ok := s.take(name, 4, limit); ok {
+			s.set(fmt.Sprintf("%d", binary.LittleEndian.Uint32(b)), true, "")
+		}
+	}
+}
+
+// modbus dissects the Modbus RTU frame up to limit (end of the V5 payload)
+func (s *dissector) modbus(limit int, isRequest bool) {
+	if s.pos >= limit {
+		return
+	}
+
+	start := s.pos
+
+	if b, ok := s.take("modbus slave", 1, limit); ok {
+		s.set(fmt.Sprintf("%d", b[0]), true, "")
+	}
+
+	b, ok := s.take("modbus function", 1, limit)
+	if !ok {
+		return
+	}
+	function := b[0]
+	s.set(fmt.Sprintf("0x%02X", function), true, "")
+
+	switch {
+	case isException(function):
+		if b, ok := s.take("exception code", 1, limit); ok {
+			s.set(ExceptionCode(b[0]).String(), true, "")
+		}
+	case isRequest:
+		s.modbusRequest(function, limit)
+	default:
+		s.modbusResponse(function, limit)
+	}
+
+	if b, ok := s.take("modbus CRC", 2, limit); ok {
+		crc := calcCRC16Modbus(s.data[start : s.pos-2])
+		got := binary.LittleEndian.Uint16(b)
+		s.set(fmt.Sprintf("0x%04X", got), got == crc, expected(got == crc, "0x%04X", crc))
+	}
+
+	if s.pos < limit {
+		s.take("padding", limit-s.pos, limit)
+	}
+}
+
+func (s *dissector) modbusRequest(function uint8, limit int) {
+	switch function {
+	case 0x01, 0x02, 0x03, 0x04:
+		s.word("start address", limit)
+		s.word("quantity", limit)
+	case 0x05, 0x06:
+		s.word("address", limit)
+		s.word("value", limit)
+	case 0x0F, 0x10:
+		s.word("start address", limit)
+		s.word("quantity", limit)
+		count, ok := s.byteCount(limit)
+		if !ok {
+			return
+		}
+		if function == 0x10 {
+			s.registers(count, limit)
+		} else {
+			s.bits(count, limit)
+		}
+	case 0x16:
+		s.word("address", limit)
+		s.word("AND mask", limit)
+		s.word("OR mask", limit)
+	}
+}
+
+func (s *dissector) modbusResponse(function uint8, limit int) {
+	switch function {
+	case 0x01, 0x02, 0x03, 0x04:
+		count, ok := s.byteCount(limit)
+		if !ok {
+			return
+		}
+		if function == 0x03 || function == 0x04 {
+			s.registers(count, limit)
+		} else {
+			s.bits(count, limit)
+		}
+	case 0x05, 0x06:
+		s.word("address", limit)
+		s.word("value", limit)
+	case 0x0F, 0x10:
+		s.word("start address", limit)
+		s.word("quantity", limit)
+	case 0x16:
+		s.word("address", limit)
+		s.word("AND mask", limit)
+		s.word("OR mask", limit)
+	}
+}
+
+func (s *dissector) word(name string, limit int) {
+	if b, ok := s.take(name, 2, limit); ok {
+		v := binary.BigEndian.Uint16(b)
+		s.set(fmt.Sprintf("0x%04X (%d)", v, v), true, "")
+	}
+}
+
+func (s *dissector) byteCount(limit int) (int, bool) {
+	b, ok := s.take("byte count", 1, limit)
+	if !ok {
+		return 0, false
+	}
+	s.set(fmt.Sprintf("%d", b[0]), true, "")
+	return int(b[0]), true
+}
+
+func (s *dissector) registers(byteCount int, limit int) {
+	for i := 0; i < byteCount/2; i++ {
+		s.word(fmt.Sprintf("register[%d]", i), limit)
+	}
+}
+
+func (s *dissector) bits(byteCount int, limit int) {
+	for i := 0; i < byteCount; i++ {
+		if b, ok := s.take(fmt.Sprintf("bits[%d-%d]", i*8, i*8+7), 1, limit); ok {
+			s.set(fmt.Sprintf("%08b", reverseBits(b[0])), true, "lowest address first")
+		}
+	}
+}
+
+func reverseBits(b byte) byte {
+	var r byte
+	for i := 0; i < 8; i++ {
+		r = r<<1 | b&1
+		b >>= 1
+	}
+	return r
+}
+
+// expected returns the note for a failed check
+func expected(ok bool, format string, want any) string {
+	if ok {
+		return ""
+	}
+	return "expected " + fmt.Sprintf(format, want)
+}
@@ -0,0 +1,82 @@
+package solarman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// -----------------------------------------------------------------------------
+// Error values
+// -----------------------------------------------------------------------------
+
+// Sentinel errors, match them with errors.Is
+var (
+	ErrChecksum              = errors.New("checksum mismatch")                  // V5 frame checksum
+	ErrCRC                   = errors.New("CRC mismatch")                       // Modbus RTU CRC16
+	ErrTimeout               = errors.New("timeout")                            // network or context deadline exceeded
+	ErrUnexpectedControlCode = errors.New("unexpected control code")            // V5 control code differs from FrameMeta
+	ErrUnexpectedMarker      = errors.New("unexpected frame marker")            // V5 start or end marker differs from FrameMeta
+	ErrShortFrame            = errors.New("short frame")                        // frame or payload ends before all fields are read
+	ErrUnexpectedResponse    = errors.New("unexpected Modbus response")         // reply does not answer the request
+	ErrNoInverterReply       = errors.New("no reply from inverter on RS485")    // logger answered without the inverter reply
+	ErrWriteDenied           = errors.New("write denied by policy")             // write rejected by WritePolicy before sending
+	ErrRegisterChanged       = errors.New("register changed by another writer") // read-modify-write found the register modified
+	ErrMissingRegister       = errors.New("missing register")                   // register needed for decoding was not read
+	ErrInvalidProfile        = errors.New("invalid profile")                    // register map definition is malformed
+)
+
+// ProtocolError is returned by all public methods of InverterLogger.
+// It carries the failing point and, when available, the raw frame involved.
+// The cause is accessible through errors.Is/errors.As.
+type ProtocolError struct {
+	LoggerSN uint32 // logger serial number
+	Op       string // failing point, e.g. "Read.exchange"
+	Msg      string // human readable description
+	Frame    []byte // raw V5 frame, nil if not available
+	Err      error  // underlying cause
+}
+
+func (e *ProtocolError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("ERROR::%s [%d] %s", e.Op, e.LoggerSN, e.Msg)
+	}
+	return fmt.Sprintf("ERROR::%s [%d] %s: %v", e.Op, e.LoggerSN, e.Msg, e.Err)
+}
+
+func (e *ProtocolError) Unwrap() error {
+	return e.Err
+}
+
+// timeoutError marks a deadline error as ErrTimeout while keeping the original cause
+type timeoutError struct {
+	err error
+}
+
+func (e *timeoutError) Error() string {
+	return e.err.Error()
+}
+
+func (e *timeoutError) Unwrap() error {
+	return e.err
+}
+
+func (e *timeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// markTimeout wraps network timeouts and exceeded context deadlines to match ErrTimeout
+func markTimeout(err error) error {
+	var ne net.Error
+	if (errors.As(err, &ne) && ne.Timeout()) || errors.Is(err, context.DeadlineExceeded) {
+		return &timeoutError{err: err}
+	}
+	return err
+}
+
+// shortFrame reports a truncated frame or payload, the cause is kept in the message only
+// so a short buffer is not mistaken for a closed connection (io.EOF)
+func shortFrame(err error) error {
+	return fmt.Errorf("%w (%v)", ErrShortFrame, err)
+}
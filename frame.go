@@ -17,6 +17,7 @@ type Frame struct {
 	SerialNumber   uint16
 	DeviceSN       uint32
 	Payload        []byte
+	raw            []byte // frame as received, set by UnmarshalBinary
 }
 
 type FrameMeta struct {
@@ -73,6 +74,7 @@ func (f *Frame) MarshalBinary(inv *InverterLogger) ([]byte, error) {
 
 func (f *Frame) UnmarshalBinary(inv *InverterLogger, data []byte) error {
 	buf := bytes.NewBuffer(data)
+	f.raw = data
 
 	startMarker := inv.Meta.StartMarker
 	endMarker := inv.Meta.EndMarker
@@ -80,35 +82,35 @@ func (f *Frame) UnmarshalBinary(inv *InverterLogger, data []byte) error {
 
 	b, err := buf.ReadByte()
 	if err != nil {
-		return fmt.Errorf("failed to read start marker - %w", err)
+		return fmt.Errorf("failed to read start marker - %w", shortFrame(err))
 	} else if b != startMarker {
-		return fmt.Errorf("expected 0x%X as start marker, got: 0x%X", startMarker, b)
+		return fmt.Errorf("%w: expected 0x%X as start marker, got: 0x%X", ErrUnexpectedMarker, startMarker, b)
 	}
 
 	if err := binary.Read(buf, binary.LittleEndian, &f.PayloadLength); err != nil {
-		return fmt.Errorf("failed to read payload length - %w", err)
+		return fmt.Errorf("failed to read payload length - %w", shortFrame(err))
 	}
 
 	if err := binary.Read(buf, binary.LittleEndian, &f.ResControlCode); err != nil {
-		return fmt.Errorf("failed to read control code - %w", err)
+		return fmt.Errorf("failed to read control code - %w", shortFrame(err))
 	} else if f.ResControlCode != resControlCode {
-		return fmt.Errorf("expected 0x%X as control code, got: 0x%X", resControlCode, f.ResControlCode)
+		return fmt.Errorf("%w: expected 0x%X, got: 0x%X", ErrUnexpectedControlCode, resControlCode, f.ResControlCode)
 	}
 
 	if err := binary.Read(buf, binary.BigEndian, &f.SerialNumber); err != nil {
-		return fmt.Errorf("failed to read serial number - %w", err)
+		return fmt.Errorf("failed to read serial number - %w", shortFrame(err))
 	}
 
 	if err := binary.Read(buf, binary.LittleEndian, &f.DeviceSN); err != nil {
-		return fmt.Errorf("failed to read device serial number - %w", err)
+		return fmt.Errorf("failed to read device serial number - %w", shortFrame(err))
 	}
 
 	f.Payload = make([]byte, f.PayloadLength)
 	n, err := buf.Read(f.Payload)
 	if err != nil {
-		return fmt.Errorf("failed to read payload - %w", err)
+		return fmt.Errorf("failed to read payload - %w", shortFrame(err))
 	} else if n != int(f.PayloadLength) {
-		return fmt.Errorf("only read %d bytes instead of %d", n, f.PayloadLength)
+		return fmt.Errorf("%w: only read %d bytes instead of %d", ErrShortFrame, n, f.PayloadLength)
 	}
 
 	// calculate expected checksum exclude startMarker & endMarker
@@ -117,16 +119,16 @@ func (f *Frame) UnmarshalBinary(inv *InverterLogger, data []byte) error {
 	// Read actual checksum
 	actualChecksum, err := buf.ReadByte()
 	if err != nil {
-		return fmt.Errorf("failed to read checksum - %w", err)
+		return fmt.Errorf("failed to read checksum - %w", shortFrame(err))
 	} else if actualChecksum != expectedChecksum {
-		return fmt.Errorf("checksum mismatch: expected 0x%X, got 0x%X", expectedChecksum, actualChecksum)
+		return fmt.Errorf("%w: expected 0x%X, got 0x%X", ErrChecksum, expectedChecksum, actualChecksum)
 	}
 
 	b, err = buf.ReadByte() // end marker
 	if err != nil {
-		return fmt.Errorf("failed to read end marker - %w", err)
+		return fmt.Errorf("failed to read end marker - %w", shortFrame(err))
 	} else if b != endMarker {
-		return fmt.Errorf("expected 0x%X as end marker, got: 0x%X", endMarker, b)
+		return fmt.Errorf("%w: expected 0x%X as end marker, got: 0x%X", ErrUnexpectedMarker, endMarker, b)
 	}
 
 	if buf.Len() != 0 {
@@ -135,3 +137,10 @@ func (f *Frame) UnmarshalBinary(inv *InverterLogger, data []byte) error {
 
 	return nil
 }
+
+// answeredBy reports whether a response with serialNumber answers the request f.
+// The logger echoes the low byte of the request sequence number
+// as the first byte of the response serial number.
+func (f *Frame) answeredBy(serialNumber uint16) bool {
+	return uint8(serialNumber>>8) == uint8(f.SerialNumber)
+}
@@ -2,4 +2,7 @@ module github.com/snowirbis/solarman
 
 go 1.18
 
-require github.com/howeyc/crc16 v0.0.0-20171223171357-2b2a61e366a6
+require (
+	github.com/howeyc/crc16 v0.0.0-20171223171357-2b2a61e366a6
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,314 @@
+package solarman
+
+import (
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// -----------------------------------------------------------------------------
+// ha-solarman inverter definition import
+// -----------------------------------------------------------------------------
+
+// haDefinition is an inverter definition file of the Home Assistant
+// solarman integration (custom_components/solarman/inverter_definitions)
+type haDefinition struct {
+	Requests []struct {
+		Start        int   `yaml:"start"`
+		End          int   `yaml:"end"`
+		FunctionCode uint8 `yaml:"mb_functioncode"`
+	} `yaml:"requests"`
+	Parameters []struct {
+		Group string    `yaml:"group"`
+		Items []haParam `yaml:"items"`
+	} `yaml:"parameters"`
+}
+
+type haParam struct {
+	Name       string    `yaml:"name"`
+	Rule       int       `yaml:"rule"`
+	Registers  []int     `yaml:"registers"`
+	Scale      *float64  `yaml:"scale"`
+	Offset     float64   `yaml:"offset"`
+	Unit       string    `yaml:"uom"`
+	Lookup     yaml.Node `yaml:"lookup"` // mapping of values to labels, some definitions use a sequence
+	Mask       *int      `yaml:"mask"`
+	Divide     *int      `yaml:"divide"`
+	Validation yaml.Node `yaml:"validation"`
+}
+
+// ImportIssue is a parameter of an imported definition that was skipped
+// or imported with part of its definition ignored
+type ImportIssue struct {
+	Group  string
+	Name   string
+	Rule   int
+	Reason string
+}
+
+func (i ImportIssue) String() string {
+	return fmt.Sprintf("%s/%s (rule %d): %s", i.Group, i.Name, i.Rule, i.Reason)
+}
+
+// ImportReport lists what could not be converted
+type ImportReport struct {
+	Skipped  []ImportIssue // parameters not imported
+	Warnings []ImportIssue // parameters imported with attributes ignored
+}
+
+func (r *ImportReport) String() string {
+	var b strings.Builder
+	for _, i := range r.Skipped {
+		fmt.Fprintf(&b, "skipped %s\n", i)
+	}
+	for _, i := range r.Warnings {
+		fmt.Fprintf(&b, "warning %s\n", i)
+	}
+	return b.String()
+}
+
+// LoadHASolarman reads an ha-solarman inverter definition file, see ImportHASolarman
+func LoadHASolarman(path string) (*Profile, *ImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("definition read failed - %w", err)
+	}
+
+	name := path[strings.LastIndexAny(path, `/\`)+1:]
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return ImportHASolarman(name, data)
+}
+
+// ImportHASolarman converts an ha-solarman inverter definition into a Profile.
+// Rules 1-4 (unsigned and signed values of one, two or four registers)
+// and 5 (ASCII string) are imported,
+// names are converted to snake case ("PV1 Power" becomes "pv1_power") and the
+// Modbus function is taken from the request covering the registers.
+// Parameters that cannot be represented are listed in the report instead of
+// failing the import.
+func ImportHASolarman(name string, data []byte) (*Profile, *ImportReport, error) {
+	var def haDefinition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+
+	p := &Profile{Name: name, Description: "imported from ha-solarman definition"}
+	report := &ImportReport{}
+	names := make(map[string]bool)
+
+	for _, group := range def.Parameters {
+		groupName := snakeCase(group.Group)
+
+		for _, item := range group.Items {
+			skip := func(format string, args ...any) {
+				report.Skipped = append(report.Skipped, ImportIssue{Group: group.Group, Name: item.Name, Rule: item.Rule, Reason: fmt.Sprintf(format, args...)})
+			}
+			warn := func(format string, args ...any) {
+				report.Warnings = append(report.Warnings, ImportIssue{Group: group.Group, Name: item.Name, Rule: item.Rule, Reason: fmt.Sprintf(format, args...)})
+			}
+
+			d := RegisterDef{
+				Name:   snakeCase(item.Name),
+				Group:  groupName,
+				Offset: item.Offset,
+				Unit:   item.Unit,
+			}
+
+			switch {
+			case d.Name == "":
+				skip("no name")
+				continue
+			case names[d.Name]:
+				skip("duplicate name %q", d.Name)
+				continue
+			case len(item.Registers) == 0:
+				skip("no registers")
+				continue
+			}
+
+			switch item.Rule {
+			case 1, 3:
+				d.Type = TypeU16
+			case 2, 4:
+				d.Type = TypeI16
+			case 5:
+				d.Type = TypeASCII
+				d.Length = len(item.Registers)
+			case 6:
+				skip("rule 6 (bit field) is not supported")
+				continue
+			case 7:
+				skip("rule 7 (version) is not supported")
+				continue
+			case 8:
+				skip("rule 8 (date and time) is not supported")
+				continue
+			case 9:
+				skip("rule 9 (time of day) is not supported")
+				continue
+			default:
+				skip("unknown rule %d", item.Rule)
+				continue
+			}
+
+			// ha-solarman lists the low word first
+			switch {
+			case d.Type == TypeASCII || len(item.Registers) == 1:
+			case len(item.Registers) == 2 && d.Type == TypeU16:
+				d.Type = TypeU32
+			case len(item.Registers) == 2:
+				d.Type = TypeI32
+			case len(item.Registers) == 4 && d.Type == TypeU16:
+				d.Type = TypeU64
+			case len(item.Registers) == 4:
+				d.Type = TypeI64
+			default:
+				skip("values of %d registers are not supported", len(item.Registers))
+				continue
+			}
+
+			d.Address = item.Registers[0]
+			for i, reg := range item.Registers {
+				if reg != item.Registers[0]+i {
+					d.Addresses = item.Registers
+					break
+				}
+			}
+			if item.Divide != nil {
+				skip("integer divide by %d is not supported", *item.Divide)
+				continue
+			}
+
+			function, ok := haFunction(&def, item.Registers)
+			if !ok {
+				skip("registers not covered by any request")
+				continue
+			}
+			if function != FuncReadHoldingRegisters {
+				d.Function = function
+			}
+
+			if item.Scale != nil {
+				d.Scale = *item.Scale
+			}
+
+			if item.Mask != nil {
+				if d.Type != TypeU16 || *item.Mask <= 0 || *item.Mask > 0xFFFF {
+					skip("mask 0x%X on %s is not supported", *item.Mask, d.Type)
+					continue
+				}
+				// ha-solarman keeps masked bits in place, Mask shifts them down
+				d.Mask = uint16(*item.Mask)
+				if shift := bits.TrailingZeros16(d.Mask); shift > 0 {
+					d.Scale = d.scale() * float64(uint(1)<<shift)
+					d.Offset /= float64(uint(1) << shift)
+				}
+			}
+
+			var lookup map[string]string
+			if !item.Lookup.IsZero() {
+				if err := item.Lookup.Decode(&lookup); err != nil {
+					skip("lookup is not a mapping of values to labels")
+					continue
+				}
+			}
+
+			keys := make([]string, 0, len(lookup))
+			for key := range lookup {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				label := lookup[key]
+				k, err := strconv.ParseInt(key, 0, 64)
+				if err != nil {
+					warn("lookup key %q ignored", key)
+					continue
+				}
+				if d.Mask != 0 {
+					// keys refer to the masked bits in place, Decode looks up the shifted field
+					if k < 0 || k&^int64(d.Mask) != 0 {
+						warn("lookup key %q outside mask 0x%X ignored", key, d.Mask)
+						continue
+					}
+					k >>= bits.TrailingZeros16(d.Mask)
+				}
+				if d.Enum == nil {
+					d.Enum = make(map[int]string)
+				}
+				d.Enum[int(k)] = label
+			}
+
+			if !item.Validation.IsZero() {
+				warn("validation ignored")
+			}
+
+			if err := d.validate(); err != nil {
+				skip("%v", err)
+				continue
+			}
+
+			names[d.Name] = true
+			p.Registers = append(p.Registers, d)
+		}
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return p, report, nil
+}
+
+// haFunction returns the function code of the request covering all registers,
+// definitions without requests are read with FuncReadHoldingRegisters
+func haFunction(def *haDefinition, registers []int) (uint8, bool) {
+	if len(def.Requests) == 0 {
+		return FuncReadHoldingRegisters, true
+	}
+
+	for _, r := range def.Requests {
+		covered := true
+		for _, reg := range registers {
+			if reg < r.Start || reg > r.End {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			if r.FunctionCode == 0 {
+				return FuncReadHoldingRegisters, true
+			}
+			return r.FunctionCode, true
+		}
+	}
+
+	return 0, false
+}
+
+// snakeCase turns "PV1 Power" into "pv1_power"
+func snakeCase(s string) string {
+	var b strings.Builder
+	sep := false
+
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package solarman
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const haMaskedLookup = `
+parameters:
+  - group: Status
+    items:
+      - name: "Grid Relay"
+        rule: 1
+        registers: [0x0010]
+        mask: 0x0F00
+        lookup:
+          0x0100: "Open"
+          0x0200: "Closed"
+          0x0001: "Stray"
+`
+
+func TestImportHASolarmanMaskedLookup(t *testing.T) {
+	p, report, err := ImportHASolarman("masked", []byte(haMaskedLookup))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0].Reason, "outside mask") {
+		t.Fatalf("warnings: %v", report.Warnings)
+	}
+
+	d, ok := p.Register("grid_relay")
+	if !ok {
+		t.Fatal("grid_relay not imported")
+	}
+
+	for raw, want := range map[uint16]string{0x8201: "Closed", 0x0100: "Open", 0x0300: "unknown (3)"} {
+		value, err := d.Decode(map[int]uint16{0x10: raw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value.Label != want {
+			t.Errorf("0x%04X: got label %q, want %q", raw, value.Label, want)
+		}
+		if want := float64(raw & 0x0F00); value.Value != want {
+			t.Errorf("0x%04X: got value %v, want %v", raw, value.Value, want)
+		}
+	}
+}
+
+const haDefinitionSnippet = `
+requests:
+  - start: 0x0003
+    end: 0x0070
+    mb_functioncode: 0x03
+  - start: 0x0200
+    end: 0x0210
+    mb_functioncode: 0x04
+
+parameters:
+  - group: Solar
+    items:
+      - name: "PV1 Power"
+        rule: 1
+        registers: [0x0010]
+        uom: "W"
+        scale: 1
+        validation:
+          max: 12000
+      - name: "Total Production"
+        rule: 3
+        registers: [0x0060, 0x0061]
+        uom: "kWh"
+        scale: 0.1
+      - name: "Battery Current"
+        rule: 2
+        registers: [0x0205]
+        uom: "A"
+        scale: 0.01
+  - group: Inverter
+    items:
+      - name: "Inverter ID"
+        rule: 5
+        registers: [0x0003, 0x0004, 0x0005]
+      - name: "Running Status"
+        rule: 1
+        registers: [0x0011]
+        lookup:
+          - key: 0
+            value: "Standby"
+          - key: 2
+            value: "Normal"
+      - name: "Work Mode"
+        rule: 1
+        registers: [0x0012]
+        lookup:
+          0: "Selling first"
+          1: "Zero export"
+      - name: "Alerts"
+        rule: 6
+        registers: [0x0013, 0x0014]
+      - name: "Outside"
+        rule: 1
+        registers: [0x0300]
+`
+
+func TestImportHASolarman(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deye_hybrid.yml")
+	if err := os.WriteFile(path, []byte(haDefinitionSnippet), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, report, err := LoadHASolarman(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name != "deye_hybrid" {
+		t.Errorf("profile name %q", p.Name)
+	}
+
+	want := map[string]RegisterDef{
+		"pv1_power":        {Group: "solar", Address: 0x10, Type: TypeU16, Scale: 1, Unit: "W"},
+		"total_production": {Group: "solar", Address: 0x60, Type: TypeU32, Scale: 0.1, Unit: "kWh"},
+		"battery_current":  {Group: "solar", Address: 0x205, Type: TypeI16, Scale: 0.01, Unit: "A", Function: FuncReadInputRegisters},
+		"inverter_id":      {Group: "inverter", Address: 0x03, Type: TypeASCII, Length: 3},
+		"work_mode":        {Group: "inverter", Address: 0x12, Type: TypeU16},
+	}
+
+	if len(p.Registers) != len(want) {
+		t.Errorf("%d registers imported, want %d", len(p.Registers), len(want))
+	}
+
+	for name, w := range want {
+		d, ok := p.Register(name)
+		if !ok {
+			t.Errorf("%s not imported", name)
+			continue
+		}
+		if d.Group != w.Group || d.Address != w.Address || d.Type != w.Type || d.Length != w.Length ||
+			d.Scale != w.Scale || d.Unit != w.Unit || d.Function != w.Function {
+			t.Errorf("%s: got %+v", name, d)
+		}
+	}
+
+	if d, _ := p.Register("work_mode"); d.Enum[1] != "Zero export" {
+		t.Errorf("work_mode labels %v", d.Enum)
+	}
+
+	skipped := make(map[string]bool)
+	for _, issue := range report.Skipped {
+		skipped[issue.Name] = true
+	}
+	for _, name := range []string{"Running Status", "Alerts", "Outside"} {
+		if !skipped[name] {
+			t.Errorf("%s not reported as skipped", name)
+		}
+	}
+	if len(report.Skipped) != 3 {
+		t.Errorf("skipped: %v", report.Skipped)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Name != "PV1 Power" {
+		t.Errorf("warnings: %v", report.Warnings)
+	}
+}
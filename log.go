@@ -1,35 +1,140 @@
 package solarman
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 func (inv *InverterLogger) error(point string, op string, err error) error {
-	if err == nil {
-		return fmt.Errorf("ERROR::%s [%d] %s", point, inv.LoggerSerialN, op)
+	return inv.frameError(point, op, nil, err)
+}
+
+// frameError is inv.error with the raw frame attached,
+// the frame of a wrapped ProtocolError is propagated up when frame is nil
+func (inv *InverterLogger) frameError(point string, op string, frame []byte, err error) error {
+	var inner *ProtocolError
+	if frame == nil && errors.As(err, &inner) {
+		frame = inner.Frame
+	}
+
+	return &ProtocolError{
+		LoggerSN: inv.LoggerSerialN,
+		Op:       point,
+		Msg:      op,
+		Frame:    frame,
+		Err:      err,
 	}
-	return fmt.Errorf("ERROR::%s [%d] %s: %w", point, inv.LoggerSerialN, op, err)
 }
 
-func (inv *InverterLogger) debug(point string, op string, frame []byte, format ...int) {
-	if !inv.DebugEnable {
-		return
+// -----------------------------------------------------------------------------
+// Structured logging
+// -----------------------------------------------------------------------------
+
+// Logger receives structured diagnostic records, args are alternating
+// key/value pairs. *slog.Logger satisfies this interface.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+// stdoutLogger prints records to stdout, used by SetDebug(true) when no Logger is set
+type stdoutLogger struct{}
+
+func (stdoutLogger) Debug(msg string, args ...any) { printRecord("DEBUG", msg, args) }
+func (stdoutLogger) Info(msg string, args ...any)  { printRecord("INFO", msg, args) }
+func (stdoutLogger) Warn(msg string, args ...any)  { printRecord("WARN", msg, args) }
+func (stdoutLogger) Error(msg string, args ...any) { printRecord("ERROR", msg, args) }
+
+func printRecord(level string, msg string, args []any) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s::%s", level, msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
 	}
 
-	fmt.Printf("DEBUG::%s [%d] %s: ", point, inv.LoggerSerialN, op)
+	fmt.Println(b.String())
+}
+
+// SetLogger routes all records to logger, level filtering is up to the logger.
+// Passing nil restores the default: stdout if debug is enabled, nothing otherwise.
+func (inv *InverterLogger) SetLogger(logger Logger) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.logger = logger
+}
 
-	asString := false
-	if len(format) > 0 && format[0] == 1 {
-		asString = true
+func (inv *InverterLogger) log() Logger {
+	if inv.logger != nil {
+		return inv.logger
+	}
+	if inv.DebugEnable {
+		return stdoutLogger{}
 	}
+	return nopLogger{}
+}
+
+// fields prepends the common logger and connection fields to args
+func (inv *InverterLogger) fields(point string, args []any) []any {
+	return append([]any{"point", point, "logger_sn", inv.LoggerSerialN, "conn_id", inv.connID}, args...)
+}
+
+func (inv *InverterLogger) debug(point string, msg string, args ...any) {
+	inv.log().Debug(msg, inv.fields(point, args)...)
+}
+
+func (inv *InverterLogger) info(point string, msg string, args ...any) {
+	inv.log().Info(msg, inv.fields(point, args)...)
+}
+
+func (inv *InverterLogger) warn(point string, msg string, args ...any) {
+	inv.log().Warn(msg, inv.fields(point, args)...)
+}
+
+func (inv *InverterLogger) logError(point string, msg string, err error, args ...any) {
+	inv.log().Error(msg, inv.fields(point, append([]any{"error", err}, args...))...)
+}
 
-	if asString {
-		fmt.Printf("%s\n", string(frame))
+// debugFrame emits a record for the raw bytes sent or received,
+// dir is "tx", "rx" or "drop"
+func (inv *InverterLogger) debugFrame(point string, msg string, dir string, frame []byte, args ...any) {
+	if _, nop := inv.log().(nopLogger); nop {
 		return
 	}
 
-	for _, b := range frame {
-		fmt.Printf("%02x ", b)
+	inv.debug(point, msg, append(inv.frameFields(dir, frame), args...)...)
+}
+
+// frameFields describes the frame for a log record
+func (inv *InverterLogger) frameFields(dir string, frame []byte) []any {
+	fields := []any{"dir", dir}
+	if len(frame) >= 7 && frame[0] == inv.Meta.StartMarker {
+		// requests carry the sequence number Little Endian, see Frame.MarshalBinary/UnmarshalBinary
+		seq := binary.BigEndian.Uint16(frame[5:7])
+		if dir == "tx" {
+			seq = binary.LittleEndian.Uint16(frame[5:7])
+		}
+		fields = append(fields, "seq", fmt.Sprintf("0x%04X", seq))
+	}
+	fields = append(fields, "len", len(frame))
+
+	if inv.DebugDissect && dir != "drop" {
+		fields = append(fields, "dissection", "\n"+DecodeMeta(frame, inv.Meta).String())
+	} else {
+		fields = append(fields, "hex", hex.EncodeToString(frame))
 	}
-	fmt.Println(" ")
+
+	return fields
 }
@@ -0,0 +1,191 @@
+package solarman
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Fake logger on the other end of net.Pipe
+// -----------------------------------------------------------------------------
+
+const testLoggerSN = 1234
+
+// newFakeLogger returns an InverterLogger connected through net.Pipe to a fake
+// logger, every request frame is passed to handle and the returned chunks are
+// written back in order
+func newFakeLogger(t *testing.T, handle func(request []byte) [][]byte) *InverterLogger {
+	t.Helper()
+
+	inv := Init("fake", testLoggerSN, 1)
+	inv.SetRetryPolicy(NoRetry)
+	inv.SetDialer(DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go serveFake(server, handle)
+		return client, nil
+	}))
+	t.Cleanup(func() { _ = inv.Close() })
+
+	return inv
+}
+
+func serveFake(conn net.Conn, handle func(request []byte) [][]byte) {
+	defer conn.Close()
+
+	for {
+		header := make([]byte, 3)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+
+		rest := make([]byte, frameHeaderLen+int(binary.LittleEndian.Uint16(header[1:3]))+frameTrailerLen-len(header))
+		if _, err := io.ReadFull(conn, rest); err != nil {
+			return
+		}
+
+		for _, chunk := range handle(append(header, rest...)) {
+			if _, err := conn.Write(chunk); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// requestSeq is the byte of the request sequence number echoed by the logger
+func requestSeq(request []byte) uint8 {
+	return request[5]
+}
+
+// requestModbus returns the Modbus RTU frame of a request without the CRC
+func requestModbus(request []byte) []byte {
+	return request[frameHeaderLen+15 : len(request)-frameTrailerLen-2]
+}
+
+// responseFrame builds a V5 response with status OK answering the request
+// with sequence byte seq, the Modbus CRC is appended to modbus
+func responseFrame(seq uint8, modbus []byte) []byte {
+	payload := make([]byte, 14+len(modbus)+2)
+	payload[0] = 0x02
+	payload[1] = uint8(StatusOK)
+	copy(payload[14:], modbus)
+	binary.LittleEndian.PutUint16(payload[14+len(modbus):], calcCRC16Modbus(modbus))
+
+	return v5Frame(seq, payload)
+}
+
+// v5Frame wraps payload into a V5 response frame with sequence byte seq
+func v5Frame(seq uint8, payload []byte) []byte {
+	frame := make([]byte, frameHeaderLen, frameHeaderLen+len(payload)+frameTrailerLen)
+	frame[0] = DefaultMeta.StartMarker
+	binary.LittleEndian.PutUint16(frame[1:3], uint16(len(payload)))
+	binary.LittleEndian.PutUint16(frame[3:5], DefaultMeta.ResControlCode)
+	frame[5], frame[6] = seq, 0x01
+	binary.LittleEndian.PutUint32(frame[7:11], testLoggerSN)
+	frame = append(frame, payload...)
+
+	return append(frame, calcCheckSum8(frame[1:]), DefaultMeta.EndMarker)
+}
+
+// readReply is the Modbus reply to a read of holding registers from slave 1
+func readReply(values ...uint16) []byte {
+	modbus := make([]byte, 3+2*len(values))
+	modbus[0], modbus[1], modbus[2] = 0x01, FuncReadHoldingRegisters, byte(2*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint16(modbus[3+2*i:], v)
+	}
+	return modbus
+}
+
+// fakeInverter keeps holding registers in memory and answers
+// reads and writes of a logger created by newFakeLogger
+type fakeInverter struct {
+	mu        sync.Mutex
+	registers map[int]uint16
+	noMask    bool                                // reject Mask Write Register with IllegalFunction
+	onWrite   func(register int, v uint16) uint16 // value stored instead of v, may change other registers
+	onRead    func(register int)                  // called before a register is read, may change registers
+	requests  [][]byte                            // Modbus part of every request
+}
+
+func newFakeInverter(t *testing.T) (*InverterLogger, *fakeInverter) {
+	t.Helper()
+
+	fake := &fakeInverter{registers: make(map[int]uint16)}
+	inv := newFakeLogger(t, func(request []byte) [][]byte {
+		return [][]byte{responseFrame(requestSeq(request), fake.handle(requestModbus(request)))}
+	})
+
+	return inv, fake
+}
+
+// functions returns the function codes of all requests received
+func (f *fakeInverter) functions() []uint8 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res := make([]uint8, len(f.requests))
+	for i, request := range f.requests {
+		res[i] = request[1]
+	}
+	return res
+}
+
+func (f *fakeInverter) handle(modbus []byte) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, append([]byte(nil), modbus...))
+
+	slaveID, function := modbus[0], modbus[1]
+	address := int(binary.BigEndian.Uint16(modbus[2:4]))
+
+	switch function {
+	case FuncReadHoldingRegisters, FuncReadInputRegisters:
+		count := int(binary.BigEndian.Uint16(modbus[4:6]))
+		reply := make([]byte, 3+2*count)
+		reply[0], reply[1], reply[2] = slaveID, function, byte(2*count)
+		for i := 0; i < count; i++ {
+			if f.onRead != nil {
+				f.onRead(address + i)
+			}
+			binary.BigEndian.PutUint16(reply[3+2*i:], f.registers[address+i])
+		}
+		return reply
+	case FuncReadCoils, FuncReadDiscreteInputs:
+		count := int(binary.BigEndian.Uint16(modbus[4:6]))
+		reply := make([]byte, 3+(count+7)/8)
+		reply[0], reply[1], reply[2] = slaveID, function, byte((count+7)/8)
+		return reply
+	case FuncWriteMultipleCoils:
+		return modbus[:6]
+	case FuncWriteSingleRegister:
+		f.store(address, binary.BigEndian.Uint16(modbus[4:6]))
+		return modbus[:6]
+	case FuncWriteMultipleRegisters:
+		count := int(binary.BigEndian.Uint16(modbus[4:6]))
+		for i := 0; i < count; i++ {
+			f.store(address+i, binary.BigEndian.Uint16(modbus[7+2*i:]))
+		}
+		return modbus[:6]
+	case FuncMaskWriteRegister:
+		if f.noMask {
+			return []byte{slaveID, function | 0x80, byte(IllegalFunction)}
+		}
+		andMask, orMask := binary.BigEndian.Uint16(modbus[4:6]), binary.BigEndian.Uint16(modbus[6:8])
+		f.store(address, maskValue(f.registers[address], andMask, orMask))
+		return modbus[:8]
+	}
+
+	return []byte{slaveID, function | 0x80, byte(IllegalFunction)}
+}
+
+func (f *fakeInverter) store(register int, v uint16) {
+	if f.onWrite != nil {
+		v = f.onWrite(register, v)
+	}
+	f.registers[register] = v
+}
@@ -0,0 +1,308 @@
+package solarman
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// -----------------------------------------------------------------------------
+// Mask Write Register (Modbus function 0x16) and bit helpers
+// -----------------------------------------------------------------------------
+
+// rmwAttempts limits the read-modify-write cycles restarted
+// because another writer changed the register
+const rmwAttempts = 3
+
+type MaskWriteRequestPayload struct {
+	FrameType    uint8
+	SensorType   uint16
+	DeliveryTime uint32
+	PowerOnTime  uint32
+	OffsetTime   uint32
+
+	DeviceAddress   uint8  // Modbus slave address, InverterLogger.SlaveID
+	FunctionCode    uint8  // Function code for mask write register = 0x16
+	RegisterAddress uint16 // Register address
+	AndMask         uint16 // bits kept from the current value
+	OrMask          uint16 // bits set where AndMask is 0
+}
+
+func (inv *InverterLogger) NewMaskWriteRequestPayload(registerAddress uint16, andMask, orMask uint16) *MaskWriteRequestPayload {
+	return &MaskWriteRequestPayload{
+		FrameType:       0x02,
+		SensorType:      0x0000,
+		DeliveryTime:    0x00000000,
+		PowerOnTime:     0x00000000,
+		OffsetTime:      0x00000000,
+		DeviceAddress:   inv.SlaveID,
+		FunctionCode:    FuncMaskWriteRegister,
+		RegisterAddress: registerAddress,
+		AndMask:         andMask,
+		OrMask:          orMask,
+	}
+}
+
+func (w *MaskWriteRequestPayload) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := marshalRequestHeader(&buf, w.FrameType, w.SensorType, w.DeliveryTime, w.PowerOnTime, w.OffsetTime); err != nil {
+		return nil, err
+	}
+
+	// 1 byte: DeviceAddress
+	// 1 byte: FunctionCode (0x16)
+	// 2 bytes: Register address (Big Endian)
+	// 2 bytes: AND mask (Big Endian)
+	// 2 bytes: OR mask (Big Endian)
+	// 2 bytes: CRC16 (Little Endian)
+	buf.Write(marshalModbusWords(w.DeviceAddress, w.FunctionCode, w.RegisterAddress, w.AndMask, w.OrMask))
+
+	return buf.Bytes(), nil
+}
+
+// maskValue is the register value after a mask write, as defined by Modbus
+func maskValue(current, andMask, orMask uint16) uint16 {
+	return current&andMask | orMask&^andMask
+}
+
+// parseMaskResponse checks the Modbus part of a 0x16 reply,
+// an echo of the request: address, function code, register, AND and OR masks, CRC16
+func parseMaskResponse(modbus []byte, deviceAddress uint8, address, andMask, orMask uint16) error {
+	if len(modbus) >= 2 && isException(modbus[1]) {
+		exception, err := unmarshalException(modbus)
+		if err != nil {
+			return fmt.Errorf("exception response unmarshal failed: %w", err)
+		}
+		return exception
+	}
+
+	if len(modbus) < 10 {
+		return fmt.Errorf("%w: mask write response of %d bytes, expected 10", ErrShortFrame, len(modbus))
+	}
+
+	crc := binary.LittleEndian.Uint16(modbus[8:10])
+	if expectedCRC := calcCRC16Modbus(modbus[:8]); crc != expectedCRC {
+		return fmt.Errorf("%w: expected 0x%X, got 0x%X", ErrCRC, expectedCRC, crc)
+	}
+
+	expected := marshalModbusWords(deviceAddress, FuncMaskWriteRegister, address, andMask, orMask)
+	if !bytes.Equal(modbus[:10], expected) {
+		return fmt.Errorf("%w: echo % X, expected % X", ErrUnexpectedResponse, modbus[:8], expected[:8])
+	}
+
+	return nil
+}
+
+// SetBits sets the bits of mask in a holding register, leaving other bits as they are
+func (inv *InverterLogger) SetBits(register int, mask uint16) error {
+	return inv.SetBitsContext(context.Background(), register, mask)
+}
+
+func (inv *InverterLogger) SetBitsContext(ctx context.Context, register int, mask uint16) error {
+	if err := inv.MaskWriteContext(ctx, register, ^mask, mask); err != nil {
+		return inv.error("SetBits.MaskWrite", "request failed", err)
+	}
+	return nil
+}
+
+// ClearBits clears the bits of mask in a holding register, leaving other bits as they are
+func (inv *InverterLogger) ClearBits(register int, mask uint16) error {
+	return inv.ClearBitsContext(context.Background(), register, mask)
+}
+
+func (inv *InverterLogger) ClearBitsContext(ctx context.Context, register int, mask uint16) error {
+	if err := inv.MaskWriteContext(ctx, register, ^mask, 0); err != nil {
+		return inv.error("ClearBits.MaskWrite", "request failed", err)
+	}
+	return nil
+}
+
+// MaskWrite changes a holding register to (current AND andMask) OR (orMask AND NOT andMask).
+// Modbus function 0x16 is used unless the device rejected it before with
+// IllegalFunction, then the register is updated by a read-modify-write
+// (functions 0x03 and 0x06) that re-reads the register right before writing it.
+// That fallback is not atomic, see readModifyWrite.
+func (inv *InverterLogger) MaskWrite(register int, andMask, orMask uint16) error {
+	return inv.MaskWriteContext(context.Background(), register, andMask, orMask)
+}
+
+func (inv *InverterLogger) MaskWriteContext(ctx context.Context, register int, andMask, orMask uint16) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	slaveID := inv.slaveID(ctx)
+
+	// with value limits the resulting value has to be known before writing
+	_, limited := inv.WritePolicy.Limits[register]
+
+	if !limited && !inv.maskUnsupported[slaveID] {
+		err := inv.maskWrite(ctx, register, andMask, orMask)
+		if !errors.Is(err, IllegalFunction) {
+			if err != nil {
+				return inv.error("MaskWrite.maskWrite", "request failed", err)
+			}
+			return inv.verifyMask(ctx, register, andMask, orMask)
+		}
+
+		inv.info("MaskWrite", "function 0x16 not supported, using read-modify-write", "slave_id", slaveID)
+		if inv.maskUnsupported == nil {
+			inv.maskUnsupported = make(map[uint8]bool)
+		}
+		inv.maskUnsupported[slaveID] = true
+	}
+
+	// read back by readModifyWrite regardless of VerifyWrites
+	if err := inv.readModifyWrite(ctx, register, andMask, orMask); err != nil {
+		return inv.error("MaskWrite.readModifyWrite", "request failed", err)
+	}
+
+	return nil
+}
+
+/* private methods */
+
+// maskWrite sends a Modbus 0x16 request, inv.mu must be held
+func (inv *InverterLogger) maskWrite(ctx context.Context, register int, andMask, orMask uint16) error {
+	if err := inv.WritePolicy.checkAddress(register, orMask); err != nil {
+		return inv.error("maskWrite.checkAddress", "write denied", err)
+	}
+
+	request := inv.NewMaskWriteRequestPayload(uint16(register), andMask, orMask)
+	request.DeviceAddress = inv.slaveID(ctx)
+
+	writePayload, err := request.MarshalBinary()
+	if err != nil {
+		return inv.error("maskWrite.writePayload", "payload marshal failed", err)
+	}
+
+	if skip, err := inv.dryRun("maskWrite.dryRun", writePayload); skip {
+		return err
+	}
+
+	err = inv.exchange(ctx, writePayload, true, func(responseFrame *Frame) error {
+		var header ResponsePayload
+		modbus, err := inv.unmarshalHeader(&header, responseFrame.Payload)
+		if err != nil {
+			return inv.frameError("maskWrite.responsePayload.unmarshalHeader", "payload unmarshal failed", responseFrame.raw, err)
+		}
+
+		err = parseMaskResponse(modbus, request.DeviceAddress, request.RegisterAddress, andMask, orMask)
+		if err = header.checkStatus(modbus, err); err != nil {
+			return inv.frameError("maskWrite.parseMaskResponse", "payload unmarshal failed", responseFrame.raw, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return inv.error("maskWrite.exchange", "request failed", err)
+	}
+
+	return nil
+}
+
+// readModifyWrite emulates 0x16 with reads and a 0x06 write, inv.mu must be held.
+// The lock only serialises writers using this InverterLogger, so the register
+// is read again right before writing. If another Modbus master changed it, the
+// value is recomputed from the new contents, up to rmwAttempts times before
+// failing with ErrRegisterChanged. A Solarman logger offers no atomic
+// read-modify-write: a change landing between that last read and the write,
+// one round trip, is still overwritten. Masked bits not taking the written
+// value are reported as VerifyError.
+func (inv *InverterLogger) readModifyWrite(ctx context.Context, register int, andMask, orMask uint16) error {
+	current, err := inv.readHolding(ctx, register)
+	if err != nil {
+		return inv.error("readModifyWrite.read", "request failed", err)
+	}
+
+	var value uint16
+
+	for attempt := 1; ; attempt++ {
+		value = maskValue(current, andMask, orMask)
+		if value == current {
+			inv.debug("readModifyWrite", "register unchanged, write skipped", "register", register, "value", value)
+			return nil
+		}
+
+		if err := inv.WritePolicy.checkWrite(register, []uint16{value}); err != nil {
+			return inv.error("readModifyWrite.checkWrite", "write denied", err)
+		}
+
+		recheck, err := inv.readHolding(ctx, register)
+		if err != nil {
+			return inv.error("readModifyWrite.recheck", "request failed", err)
+		}
+
+		if recheck == current {
+			break
+		}
+
+		if attempt >= rmwAttempts {
+			return inv.error("readModifyWrite.recheck", "register keeps changing",
+				fmt.Errorf("%w: 0x%X changed from %d to %d", ErrRegisterChanged, register, current, recheck))
+		}
+
+		inv.warn("readModifyWrite", "register changed, recomputing", "register", register, "attempt", attempt)
+		current = recheck
+	}
+
+	request := inv.NewWriteSingleRequestPayload(uint16(register), value)
+	request.DeviceAddress = inv.slaveID(ctx)
+
+	writePayload, err := request.MarshalBinary()
+	if err != nil {
+		return inv.error("readModifyWrite.writePayload", "payload marshal failed", err)
+	}
+
+	err = inv.writeEcho(ctx, writePayload, request.DeviceAddress, request.FunctionCode, request.RegisterAddress, request.RegisterValue)
+	if err != nil {
+		return inv.error("readModifyWrite.writeEcho", "request failed", err)
+	}
+
+	if inv.WritePolicy.DryRun {
+		return nil
+	}
+
+	// bits outside the mask may be changed by other writers meanwhile, only masked bits are compared
+	actual, err := inv.readHolding(ctx, register)
+	if err != nil {
+		return inv.error("readModifyWrite.readBack", "read back failed", err)
+	}
+
+	if requested := maskValue(actual, andMask, orMask); requested != actual {
+		return inv.error("readModifyWrite.readBack", "verification failed",
+			&VerifyError{Start: register, Requested: []uint16{requested}, Actual: []uint16{actual}})
+	}
+
+	return nil
+}
+
+// verifyMask checks the masked bits when write verification is enabled, inv.mu must be held
+func (inv *InverterLogger) verifyMask(ctx context.Context, register int, andMask, orMask uint16) error {
+	if !inv.VerifyWrites || inv.WritePolicy.DryRun {
+		return nil
+	}
+
+	actual, err := inv.readHolding(ctx, register)
+	if err != nil {
+		return inv.error("MaskWrite.verifyMask", "read back failed", err)
+	}
+
+	// bits kept by the AND mask may have been changed meanwhile, only masked bits are compared
+	if requested := maskValue(actual, andMask, orMask); requested != actual {
+		return inv.error("MaskWrite.verifyMask", "verification failed",
+			&VerifyError{Start: register, Requested: []uint16{requested}, Actual: []uint16{actual}})
+	}
+
+	return nil
+}
+
+// readHolding reads one holding register, inv.mu must be held
+func (inv *InverterLogger) readHolding(ctx context.Context, register int) (uint16, error) {
+	registers, err := inv.readRegisters(ctx, FuncReadHoldingRegisters, register, 1)
+	if err != nil {
+		return 0, err
+	}
+	return registers[register], nil
+}
@@ -0,0 +1,123 @@
+package solarman
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSetBits(t *testing.T) {
+	inv, fake := newFakeInverter(t)
+	fake.registers[5] = 0x00F0
+
+	if err := inv.SetBits(5, 0x0003); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.ClearBits(5, 0x0030); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fake.registers[5]; got != 0x00C3 {
+		t.Fatalf("got 0x%04X, want 0x00C3", got)
+	}
+	if got := fake.functions(); !reflect.DeepEqual(got, []uint8{FuncMaskWriteRegister, FuncMaskWriteRegister}) {
+		t.Fatalf("functions % X", got)
+	}
+}
+
+func TestSetBitsReadModifyWrite(t *testing.T) {
+	inv, fake := newFakeInverter(t)
+	fake.noMask = true
+	fake.registers[5] = 0x00F0
+
+	if err := inv.SetBits(5, 0x0003); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.ClearBits(5, 0x0030); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fake.registers[5]; got != 0x00C3 {
+		t.Fatalf("got 0x%04X, want 0x00C3", got)
+	}
+
+	// 0x16 is tried once, every fallback reads, re-reads, writes and reads back
+	read, write := FuncReadHoldingRegisters, FuncWriteSingleRegister
+	want := []uint8{
+		FuncMaskWriteRegister,
+		read, read, write, read,
+		read, read, write, read,
+	}
+	if got := fake.functions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("functions % X, want % X", got, want)
+	}
+}
+
+func TestReadModifyWriteChanged(t *testing.T) {
+	inv, fake := newFakeInverter(t)
+	fake.noMask = true
+	fake.registers[5] = 0x00F0
+
+	// another master sets bit 15 between the first read and the re-read
+	reads := 0
+	fake.onRead = func(register int) {
+		if reads++; reads == 2 {
+			fake.registers[register] |= 0x8000
+		}
+	}
+
+	if err := inv.SetBits(5, 0x0003); err != nil {
+		t.Fatal(err)
+	}
+
+	// the value is recomputed from the changed register
+	if got := fake.registers[5]; got != 0x80F3 {
+		t.Fatalf("got 0x%04X, want 0x80F3", got)
+	}
+}
+
+func TestReadModifyWriteKeepsChanging(t *testing.T) {
+	inv, fake := newFakeInverter(t)
+	fake.noMask = true
+	fake.onRead = func(register int) { fake.registers[register] += 0x0100 }
+
+	if err := inv.SetBits(5, 0x0003); !errors.Is(err, ErrRegisterChanged) {
+		t.Fatalf("got %v, want ErrRegisterChanged", err)
+	}
+
+	for _, function := range fake.functions() {
+		if function == FuncWriteSingleRegister {
+			t.Fatal("register written")
+		}
+	}
+}
+
+func TestReadModifyWriteReadBack(t *testing.T) {
+	tests := []struct {
+		name    string
+		onWrite func(register int, v uint16) uint16
+		ok      bool
+	}{
+		// changed after our write: the write succeeded, not an error
+		{"other bits changed", func(register int, v uint16) uint16 { return v | 0x8000 }, true},
+		{"masked bits rejected", func(register int, v uint16) uint16 { return v &^ 0x0001 }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, fake := newFakeInverter(t)
+			fake.noMask = true
+			fake.onWrite = tt.onWrite
+
+			err := inv.SetBits(5, 0x0003)
+
+			var verifyErr *VerifyError
+			switch {
+			case tt.ok && err != nil:
+				t.Fatal(err)
+			case !tt.ok && !errors.As(err, &verifyErr):
+				t.Fatalf("got %v, want VerifyError", err)
+			}
+		})
+	}
+}
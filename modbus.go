@@ -0,0 +1,108 @@
+package solarman
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Modbus function codes
+const (
+	FuncReadCoils              uint8 = 0x01
+	FuncReadDiscreteInputs     uint8 = 0x02
+	FuncReadHoldingRegisters   uint8 = 0x03
+	FuncReadInputRegisters     uint8 = 0x04
+	FuncWriteSingleCoil        uint8 = 0x05
+	FuncWriteSingleRegister    uint8 = 0x06
+	FuncWriteMultipleCoils     uint8 = 0x0F
+	FuncWriteMultipleRegisters uint8 = 0x10
+	FuncMaskWriteRegister      uint8 = 0x16
+)
+
+// -----------------------------------------------------------------------------
+// Modbus exception responses (function code | 0x80)
+// -----------------------------------------------------------------------------
+
+// ExceptionCode is the Modbus exception code sent by the device
+// when it rejects a request. It can be matched with errors.Is.
+type ExceptionCode uint8
+
+const (
+	IllegalFunction                    ExceptionCode = 0x01
+	IllegalDataAddress                 ExceptionCode = 0x02
+	IllegalDataValue                   ExceptionCode = 0x03
+	SlaveDeviceFailure                 ExceptionCode = 0x04
+	Acknowledge                        ExceptionCode = 0x05
+	SlaveDeviceBusy                    ExceptionCode = 0x06
+	NegativeAcknowledge                ExceptionCode = 0x07
+	MemoryParityError                  ExceptionCode = 0x08
+	GatewayPathUnavailable             ExceptionCode = 0x0A
+	GatewayTargetDeviceFailedToRespond ExceptionCode = 0x0B
+)
+
+var exceptionNames = map[ExceptionCode]string{
+	IllegalFunction:                    "IllegalFunction",
+	IllegalDataAddress:                 "IllegalDataAddress",
+	IllegalDataValue:                   "IllegalDataValue",
+	SlaveDeviceFailure:                 "SlaveDeviceFailure",
+	Acknowledge:                        "Acknowledge",
+	SlaveDeviceBusy:                    "SlaveDeviceBusy",
+	NegativeAcknowledge:                "NegativeAcknowledge",
+	MemoryParityError:                  "MemoryParityError",
+	GatewayPathUnavailable:             "GatewayPathUnavailable",
+	GatewayTargetDeviceFailedToRespond: "GatewayTargetDeviceFailedToRespond",
+}
+
+func (c ExceptionCode) String() string {
+	if name, ok := exceptionNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("ExceptionCode(0x%02X)", uint8(c))
+}
+
+func (c ExceptionCode) Error() string {
+	return "Modbus exception " + c.String()
+}
+
+// ModbusException is returned when the device replies with an exception response
+type ModbusException struct {
+	DeviceAddress uint8         // Modbus slave address of the reply
+	FunctionCode  uint8         // function code of the rejected request (without 0x80 bit)
+	Code          ExceptionCode // exception code
+}
+
+func (e *ModbusException) Error() string {
+	return fmt.Sprintf("Modbus exception 0x%02X (%s) for function 0x%02X from device %d",
+		uint8(e.Code), e.Code.String(), e.FunctionCode, e.DeviceAddress)
+}
+
+// Is allows errors.Is(err, solarman.IllegalDataAddress)
+func (e *ModbusException) Is(target error) bool {
+	code, ok := target.(ExceptionCode)
+	return ok && code == e.Code
+}
+
+func isException(functionCode uint8) bool {
+	return functionCode&0x80 != 0
+}
+
+// unmarshalException decodes the Modbus part of an exception response:
+// 1 byte: DeviceAddress
+// 1 byte: FunctionCode | 0x80
+// 1 byte: ExceptionCode
+// 2 bytes: CRC16 (Little Endian)
+func unmarshalException(data []byte) (*ModbusException, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("%w: exception response of %d bytes, expected 5", ErrShortFrame, len(data))
+	}
+
+	crc := binary.LittleEndian.Uint16(data[3:5])
+	if expectedCRC := calcCRC16Modbus(data[:3]); crc != expectedCRC {
+		return nil, fmt.Errorf("%w: expected 0x%X, got 0x%X", ErrCRC, expectedCRC, crc)
+	}
+
+	return &ModbusException{
+		DeviceAddress: data[0],
+		FunctionCode:  data[1] &^ 0x80,
+		Code:          ExceptionCode(data[2]),
+	}, nil
+}
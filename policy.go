@@ -0,0 +1,149 @@
+package solarman
+
+import (
+	"fmt"
+)
+
+// -----------------------------------------------------------------------------
+// Write policy
+// -----------------------------------------------------------------------------
+
+// RegisterRange is a block of consecutive registers (or coils)
+type RegisterRange struct {
+	Start int // first address
+	Count int // number of addresses
+}
+
+func (r RegisterRange) contains(address int) bool {
+	return address >= r.Start && address < r.Start+r.Count
+}
+
+// ValueRange limits the value written to a register, bounds included.
+// A negative Min marks the register as signed, the written word is then
+// compared as int16, e.g. {Min: -500, Max: 500} for a battery current.
+type ValueRange struct {
+	Min int
+	Max int
+}
+
+// contains reports whether the register word value is within the range
+func (r ValueRange) contains(value uint16) bool {
+	v := int(value)
+	if r.Min < 0 {
+		v = int(int16(value))
+	}
+	return v >= r.Min && v <= r.Max
+}
+
+// WritePolicy guards writes of InverterLogger. The zero value allows everything.
+//
+//	inv.SetWritePolicy(solarman.WritePolicy{
+//		Allow:  []solarman.RegisterRange{{Start: 0x3E, Count: 3}, {Start: 0x6C, Count: 1}},
+//		Limits: map[int]solarman.ValueRange{0x6C: {Min: 0, Max: 100}},
+//	})
+type WritePolicy struct {
+	ReadOnly   bool               // reject all writes
+	DryRun     bool               // build and log write frames without sending them
+	Allow      []RegisterRange    // writable registers, nil allows all
+	Limits     map[int]ValueRange // allowed values per register, registers not listed take any value
+	AllowCoils []RegisterRange    // writable coils, nil allows all
+}
+
+// WritePolicyError is returned for writes rejected by WritePolicy,
+// nothing is sent to the logger. It matches ErrWriteDenied.
+type WritePolicyError struct {
+	Address int    // register or coil address
+	Value   uint16 // rejected value, OR mask for masked writes rejected before reading
+	Reason  string // "read-only", "address not allowed" or "value out of range"
+}
+
+func (e *WritePolicyError) Error() string {
+	return fmt.Sprintf("%v: 0x%X = %d: %s", ErrWriteDenied, e.Address, e.Value, e.Reason)
+}
+
+func (e *WritePolicyError) Unwrap() error {
+	return ErrWriteDenied
+}
+
+// SetWritePolicy replaces the write policy
+func (inv *InverterLogger) SetWritePolicy(policy WritePolicy) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.WritePolicy = policy
+}
+
+// checkWrite validates values written to consecutive registers starting from start
+func (p WritePolicy) checkWrite(start int, values []uint16) error {
+	for i, value := range values {
+		address := start + i
+
+		if err := p.checkAddress(address, value); err != nil {
+			return err
+		}
+
+		if limit, ok := p.Limits[address]; ok && !limit.contains(value) {
+			return &WritePolicyError{Address: address, Value: value,
+				Reason: fmt.Sprintf("value out of range %d..%d", limit.Min, limit.Max)}
+		}
+	}
+
+	return nil
+}
+
+// checkAddress validates the register is writable at all, value is only reported
+func (p WritePolicy) checkAddress(address int, value uint16) error {
+	switch {
+	case p.ReadOnly:
+		return &WritePolicyError{Address: address, Value: value, Reason: "read-only"}
+	case p.Allow != nil && !allowed(p.Allow, address):
+		return &WritePolicyError{Address: address, Value: value, Reason: "address not allowed"}
+	}
+	return nil
+}
+
+// checkCoils validates values written to consecutive coils starting from start
+func (p WritePolicy) checkCoils(start int, values []bool) error {
+	for i, value := range values {
+		address := start + i
+
+		state := uint16(0)
+		if value {
+			state = 1
+		}
+
+		switch {
+		case p.ReadOnly:
+			return &WritePolicyError{Address: address, Value: state, Reason: "read-only"}
+		case p.AllowCoils != nil && !allowed(p.AllowCoils, address):
+			return &WritePolicyError{Address: address, Value: state, Reason: "address not allowed"}
+		}
+	}
+
+	return nil
+}
+
+func allowed(ranges []RegisterRange, address int) bool {
+	for _, r := range ranges {
+		if r.contains(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRun logs the write frame instead of sending it when WritePolicy.DryRun is set,
+// returns true if the write must be skipped
+func (inv *InverterLogger) dryRun(point string, writePayload []byte) (bool, error) {
+	if !inv.WritePolicy.DryRun {
+		return false, nil
+	}
+
+	frame, err := inv.NewFrame(inv.LoggerSerialN, writePayload).MarshalBinary(inv)
+	if err != nil {
+		return true, inv.error(point, "frame marshal failed", err)
+	}
+
+	inv.info(point, "write skipped, dry run", inv.frameFields("tx", frame)...)
+
+	return true, nil
+}
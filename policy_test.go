@@ -0,0 +1,67 @@
+package solarman
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWritePolicyLimits(t *testing.T) {
+	policy := WritePolicy{
+		Limits: map[int]ValueRange{
+			0x10: {Min: 0, Max: 100},
+			0x11: {Min: -500, Max: 500},
+			0x12: {Min: -2000, Max: -100},
+			0x13: {Min: 0, Max: 0xFFFF},
+		},
+	}
+
+	tests := []struct {
+		address int
+		value   int
+		allowed bool
+	}{
+		{0x10, 0, true},
+		{0x10, 100, true},
+		{0x10, 101, false},
+		{0x10, -1, false},
+		{0x11, -500, true},
+		{0x11, -501, false},
+		{0x11, 500, true},
+		{0x11, 501, false},
+		{0x11, 0x8000, false},
+		{0x12, -100, true},
+		{0x12, -2000, true},
+		{0x12, 0, false},
+		{0x12, -99, false},
+		{0x13, 0xFFFF, true},
+		{0x14, 0xFFFF, true},
+	}
+
+	for _, tt := range tests {
+		err := policy.checkWrite(tt.address, []uint16{uint16(tt.value)})
+
+		var policyErr *WritePolicyError
+		switch {
+		case tt.allowed && err != nil:
+			t.Errorf("0x%X = %d: unexpected error %v", tt.address, tt.value, err)
+		case !tt.allowed && !errors.As(err, &policyErr):
+			t.Errorf("0x%X = %d: got %v, want WritePolicyError", tt.address, tt.value, err)
+		case !tt.allowed && policyErr.Address != tt.address:
+			t.Errorf("0x%X = %d: error for address 0x%X", tt.address, tt.value, policyErr.Address)
+		}
+	}
+}
+
+func TestWritePolicyAddresses(t *testing.T) {
+	policy := WritePolicy{Allow: []RegisterRange{{Start: 0x3E, Count: 3}}}
+
+	if err := policy.checkWrite(0x3E, []uint16{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.checkWrite(0x3F, []uint16{1, 2, 3}); !errors.Is(err, ErrWriteDenied) {
+		t.Fatalf("got %v, want ErrWriteDenied", err)
+	}
+	if err := (WritePolicy{ReadOnly: true}).checkWrite(0x3E, []uint16{1}); !errors.Is(err, ErrWriteDenied) {
+		t.Fatalf("got %v, want ErrWriteDenied", err)
+	}
+}
@@ -0,0 +1,526 @@
+package solarman
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// -----------------------------------------------------------------------------
+// Declarative register maps
+// -----------------------------------------------------------------------------
+
+// DataType is the encoding of a register value
+type DataType string
+
+const (
+	TypeU16 DataType = "u16" // unsigned 16-bit, one register
+	TypeI16 DataType = "i16" // signed 16-bit, one register
+	TypeU32 DataType = "u32" // unsigned 32-bit, two registers
+	TypeI32 DataType = "i32" // signed 32-bit, two registers
+	TypeU64 DataType = "u64" // unsigned 64-bit, four registers
+	TypeI64 DataType = "i64" // signed 64-bit, four registers
+
+	TypeASCII DataType = "ascii" // two characters per register, Length registers
+	TypeBCD   DataType = "bcd"   // four decimal digits per register, Length registers
+)
+
+// Access tells whether a register may be written
+type Access string
+
+const (
+	AccessRead      Access = "r"
+	AccessReadWrite Access = "rw"
+)
+
+// RegisterDef describes one value of a register map.
+// The decoded value is (raw - Offset) * Scale, e.g. battery temperature
+// "(C - 1000) * 0.1" is Offset 1000, Scale 0.1.
+type RegisterDef struct {
+	Name      string         `json:"name" yaml:"name"`
+	Group     string         `json:"group,omitempty" yaml:"group,omitempty"`
+	Address   int            `json:"address" yaml:"address"`
+	Addresses []int          `json:"addresses,omitempty" yaml:"addresses,omitempty"`   // registers in value order for values split across non-contiguous registers, overrides Address
+	Length    int            `json:"length,omitempty" yaml:"length,omitempty"`         // registers, derived from Type when 0, required for ascii and bcd
+	Type      DataType       `json:"type,omitempty" yaml:"type,omitempty"`             // TypeU16 when empty
+	WordOrder WordOrder      `json:"word_order,omitempty" yaml:"word_order,omitempty"` // LowWordFirst when empty
+	ByteOrder ByteOrder      `json:"byte_order,omitempty" yaml:"byte_order,omitempty"` // HighByteFirst when empty
+	Mask      uint16         `json:"mask,omitempty" yaml:"mask,omitempty"`             // bits of a u16 register shifted down to bit 0, e.g. 0xFF00 for the high byte
+	Scale     float64        `json:"scale,omitempty" yaml:"scale,omitempty"`           // 1 when 0
+	Offset    float64        `json:"offset,omitempty" yaml:"offset,omitempty"`
+	Unit      string         `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Enum      map[int]string `json:"enum,omitempty" yaml:"enum,omitempty"`         // labels of raw values
+	Access    Access         `json:"access,omitempty" yaml:"access,omitempty"`     // AccessRead when empty
+	Function  uint8          `json:"function,omitempty" yaml:"function,omitempty"` // FuncReadHoldingRegisters when 0
+	SlaveID   uint8          `json:"slave_id,omitempty" yaml:"slave_id,omitempty"` // Modbus slave address of the device, Profile.SlaveID when 0
+}
+
+// Profile is a register map of an inverter model
+type Profile struct {
+	Name        string        `json:"name" yaml:"name"`
+	Version     string        `json:"version,omitempty" yaml:"version,omitempty"`
+	Description string        `json:"description,omitempty" yaml:"description,omitempty"`
+	SlaveID     uint8         `json:"slave_id,omitempty" yaml:"slave_id,omitempty"` // default Modbus slave address, see readDefs for precedence
+	Registers   []RegisterDef `json:"registers" yaml:"registers"`
+}
+
+// Value is a decoded register map entry
+type Value struct {
+	Name  string
+	Group string
+	Unit  string
+	Value float64
+	Label string   // enum label of the raw value, empty if the entry has no enum
+	Text  string   // decoded ascii and bcd entries
+	Raw   []uint16 // registers the value was decoded from
+}
+
+func (v Value) String() string {
+	if v.Label != "" {
+		return v.Label
+	}
+	if v.Text != "" {
+		return v.Text
+	}
+	s := strconv.FormatFloat(v.Value, 'f', -1, 64)
+	if v.Unit != "" {
+		s += " " + v.Unit
+	}
+	return s
+}
+
+// LoadProfile reads a register map from a .json, .yaml or .yml file
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("profile read failed - %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParseProfileJSON(data)
+	case ".yaml", ".yml":
+		return ParseProfileYAML(data)
+	default:
+		return nil, fmt.Errorf("%w: unknown file extension %q", ErrInvalidProfile, filepath.Ext(path))
+	}
+}
+
+// ParseProfileJSON decodes and validates a JSON register map
+func ParseProfileJSON(data []byte) (*Profile, error) {
+	var p Profile
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ParseProfileYAML decodes and validates a YAML register map.
+// Addresses may be written in hex (0x6D).
+func ParseProfileYAML(data []byte) (*Profile, error) {
+	var p Profile
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks names are unique and every entry is decodable
+func (p *Profile) Validate() error {
+	names := make(map[string]bool, len(p.Registers))
+
+	for i := range p.Registers {
+		d := &p.Registers[i]
+
+		if d.Name == "" {
+			return fmt.Errorf("%w: entry %d at 0x%X has no name", ErrInvalidProfile, i, d.Address)
+		}
+		if names[d.Name] {
+			return fmt.Errorf("%w: duplicate name %q", ErrInvalidProfile, d.Name)
+		}
+		names[d.Name] = true
+
+		if err := d.validate(); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidProfile, d.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Register returns the entry called name
+func (p *Profile) Register(name string) (RegisterDef, bool) {
+	for _, d := range p.Registers {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return RegisterDef{}, false
+}
+
+// Group returns the entries of a group in profile order
+func (p *Profile) Group(group string) []RegisterDef {
+	var defs []RegisterDef
+	for _, d := range p.Registers {
+		if d.Group == group {
+			defs = append(defs, d)
+		}
+	}
+	return defs
+}
+
+// Groups returns the group names in order of first appearance
+func (p *Profile) Groups() []string {
+	var groups []string
+	seen := make(map[string]bool)
+	for _, d := range p.Registers {
+		if !seen[d.Group] {
+			seen[d.Group] = true
+			groups = append(groups, d.Group)
+		}
+	}
+	return groups
+}
+
+// Decode decodes every entry of the profile from registers read before
+func (p *Profile) Decode(registers map[int]uint16) (map[string]Value, error) {
+	return decodeDefs(p.Registers, registers)
+}
+
+func (d *RegisterDef) validate() error {
+	if d.Address < 0 || d.Address > 0xFFFF {
+		return fmt.Errorf("address 0x%X out of range", d.Address)
+	}
+	for _, address := range d.Addresses {
+		if address < 0 || address > 0xFFFF {
+			return fmt.Errorf("address 0x%X out of range", address)
+		}
+	}
+
+	width, ok := typeWidths[d.dataType()]
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown type %q", d.Type)
+	case width == 0 && d.Length <= 0 && d.Addresses == nil:
+		return fmt.Errorf("type %s needs a length", d.dataType())
+	case width == 0:
+		// text of any length
+	case d.Length != 0 && d.Length != width:
+		return fmt.Errorf("length %d does not match type %s of %d registers", d.Length, d.dataType(), width)
+	case d.Addresses != nil && len(d.Addresses) != width:
+		return fmt.Errorf("%d addresses do not match type %s of %d registers", len(d.Addresses), d.dataType(), width)
+	}
+	if d.Length != 0 && d.Addresses != nil && len(d.Addresses) != d.Length {
+		return fmt.Errorf("%d addresses do not match length %d", len(d.Addresses), d.Length)
+	}
+	if last := d.Address + len(d.Registers()) - 1; d.Addresses == nil && last > 0xFFFF {
+		return fmt.Errorf("registers 0x%X-0x%X out of range", d.Address, last)
+	}
+
+	if d.Mask != 0 && d.dataType() != TypeU16 {
+		return fmt.Errorf("mask applies to type u16 only")
+	}
+
+	switch d.byteOrder() {
+	case HighByteFirst, LowByteFirst:
+	default:
+		return fmt.Errorf("unknown byte order %q", d.ByteOrder)
+	}
+
+	switch d.wordOrder() {
+	case LowWordFirst, HighWordFirst:
+	default:
+		return fmt.Errorf("unknown word order %q", d.WordOrder)
+	}
+
+	switch d.access() {
+	case AccessRead, AccessReadWrite:
+	default:
+		return fmt.Errorf("unknown access %q", d.Access)
+	}
+
+	switch d.function() {
+	case FuncReadHoldingRegisters, FuncReadInputRegisters:
+	default:
+		return fmt.Errorf("function 0x%02X is not a register read", d.Function)
+	}
+
+	return nil
+}
+
+var typeWidths = map[DataType]int{
+	TypeU16: 1,
+	TypeI16: 1,
+	TypeU32: 2,
+	TypeI32: 2,
+	TypeU64: 4,
+	TypeI64: 4,
+
+	// variable length
+	TypeASCII: 0,
+	TypeBCD:   0,
+}
+
+func (d *RegisterDef) dataType() DataType {
+	if d.Type == "" {
+		return TypeU16
+	}
+	return d.Type
+}
+
+func (d *RegisterDef) wordOrder() WordOrder {
+	if d.WordOrder == "" {
+		return LowWordFirst
+	}
+	return d.WordOrder
+}
+
+func (d *RegisterDef) byteOrder() ByteOrder {
+	if d.ByteOrder == "" {
+		return HighByteFirst
+	}
+	return d.ByteOrder
+}
+
+func (d *RegisterDef) access() Access {
+	if d.Access == "" {
+		return AccessRead
+	}
+	return d.Access
+}
+
+func (d *RegisterDef) function() uint8 {
+	if d.Function == 0 {
+		return FuncReadHoldingRegisters
+	}
+	return d.Function
+}
+
+func (d *RegisterDef) scale() float64 {
+	if d.Scale == 0 {
+		return 1
+	}
+	return d.Scale
+}
+
+// Registers returns the registers the entry is decoded from
+func (d *RegisterDef) Registers() []int {
+	if d.Addresses != nil {
+		return d.Addresses
+	}
+
+	width := d.Length
+	if width == 0 {
+		width = typeWidths[d.dataType()]
+	}
+
+	addresses := make([]int, width)
+	for i := range addresses {
+		addresses[i] = d.Address + i
+	}
+	return addresses
+}
+
+// Writable reports whether the entry is marked AccessReadWrite
+func (d *RegisterDef) Writable() bool {
+	return d.access() == AccessReadWrite
+}
+
+// Decode decodes the entry from registers read before
+func (d *RegisterDef) Decode(registers map[int]uint16) (Value, error) {
+	if err := d.validate(); err != nil {
+		return Value{}, fmt.Errorf("%w: %s: %v", ErrInvalidProfile, d.Name, err)
+	}
+
+	addresses := d.Registers()
+	raw := make([]uint16, len(addresses))
+	for i, address := range addresses {
+		v, ok := registers[address]
+		if !ok {
+			return Value{}, fmt.Errorf("%w: 0x%X for %s", ErrMissingRegister, address, d.Name)
+		}
+		raw[i] = v
+	}
+
+	value := Value{
+		Name:  d.Name,
+		Group: d.Group,
+		Unit:  d.Unit,
+		Raw:   raw,
+	}
+
+	switch d.dataType() {
+	case TypeASCII:
+		value.Text = decodeASCII(raw, d.byteOrder())
+		return value, nil
+	case TypeBCD:
+		text, err := decodeBCD(raw)
+		if err != nil {
+			return Value{}, fmt.Errorf("%s: %w", d.Name, err)
+		}
+		value.Text = text
+		return value, nil
+	}
+
+	u := decodeUint(raw, d.wordOrder(), d.byteOrder())
+	if d.Mask != 0 {
+		u = uint64(extractField(uint16(u), d.Mask))
+	}
+
+	var n float64
+	var key int
+
+	switch d.dataType() {
+	case TypeI16, TypeI32, TypeI64:
+		i := signExtend(u, len(raw))
+		n, key = float64(i), int(i)
+	default:
+		n, key = float64(u), int(u)
+	}
+
+	value.Value = Scaled(n, d.scale(), d.Offset)
+
+	if d.Enum != nil {
+		value.Label = enumLabel(d.Enum, key)
+	}
+
+	return value, nil
+}
+
+func decodeDefs(defs []RegisterDef, registers map[int]uint16) (map[string]Value, error) {
+	res := make(map[string]Value, len(defs))
+	for i := range defs {
+		value, err := defs[i].Decode(registers)
+		if err != nil {
+			return nil, err
+		}
+		res[value.Name] = value
+	}
+	return res, nil
+}
+
+// -----------------------------------------------------------------------------
+// Profile reads
+// -----------------------------------------------------------------------------
+
+// ReadProfile reads all entries of the profile with the fewest requests
+// (see ReadMany) and returns the decoded values by name
+func (inv *InverterLogger) ReadProfile(p *Profile) (map[string]Value, error) {
+	return inv.ReadProfileContext(context.Background(), p)
+}
+
+func (inv *InverterLogger) ReadProfileContext(ctx context.Context, p *Profile) (map[string]Value, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	res, err := inv.readDefs(ctx, p.SlaveID, p.Registers)
+	if err != nil {
+		return nil, inv.error("ReadProfile.readDefs", "request failed", err)
+	}
+
+	return res, nil
+}
+
+// ReadGroup reads the entries of one group of the profile
+func (inv *InverterLogger) ReadGroup(p *Profile, group string) (map[string]Value, error) {
+	return inv.ReadGroupContext(context.Background(), p, group)
+}
+
+func (inv *InverterLogger) ReadGroupContext(ctx context.Context, p *Profile, group string) (map[string]Value, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	defs := p.Group(group)
+	if len(defs) == 0 {
+		return nil, inv.error("ReadGroup", "unknown group", fmt.Errorf("%w: no entries in group %q", ErrInvalidProfile, group))
+	}
+
+	res, err := inv.readDefs(ctx, p.SlaveID, defs)
+	if err != nil {
+		return nil, inv.error("ReadGroup.readDefs", "request failed", err)
+	}
+
+	return res, nil
+}
+
+// readBlock is a set of registers read with the same slave address and function
+type readBlock struct {
+	slaveID  uint8
+	function uint8
+}
+
+// readDefs reads the registers of defs, grouped by slave address and function
+// (holding or input registers), and decodes them. The slave address of the
+// entry wins, as it selects another device on the bus. Other entries are read
+// from the address set by WithSlaveID or SetSlaveID, else the profile default
+// slaveID, else inv.SlaveID. inv.mu must be held.
+func (inv *InverterLogger) readDefs(ctx context.Context, slaveID uint8, defs []RegisterDef) (map[string]Value, error) {
+	byBlock := make(map[readBlock][]int)
+	var blocks []readBlock
+
+	defaultID := inv.slaveID(ctx)
+	if _, overridden := slaveIDOverride(ctx); !overridden && !inv.slaveIDSet && slaveID != 0 {
+		defaultID = slaveID
+	}
+
+	blockOf := func(d *RegisterDef) readBlock {
+		b := readBlock{slaveID: d.SlaveID, function: d.function()}
+		if b.slaveID == 0 {
+			b.slaveID = defaultID
+		}
+		return b
+	}
+
+	for i := range defs {
+		if err := defs[i].validate(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidProfile, defs[i].Name, err)
+		}
+
+		b := blockOf(&defs[i])
+		if _, ok := byBlock[b]; !ok {
+			blocks = append(blocks, b)
+		}
+		byBlock[b] = append(byBlock[b], defs[i].Registers()...)
+	}
+
+	registers := make(map[readBlock]map[int]uint16, len(blocks))
+	for _, b := range blocks {
+		res, err := inv.readMany(WithSlaveID(ctx, b.slaveID), b.function, byBlock[b])
+		if err != nil {
+			return nil, err
+		}
+		registers[b] = res
+	}
+
+	res := make(map[string]Value, len(defs))
+	for i := range defs {
+		value, err := defs[i].Decode(registers[blockOf(&defs[i])])
+		if err != nil {
+			return nil, err
+		}
+		res[value.Name] = value
+	}
+
+	return res, nil
+}
@@ -0,0 +1,114 @@
+package solarman
+
+import (
+	"context"
+	"testing"
+)
+
+const slaveProfile = `
+name: meter
+slave_id: 4
+registers:
+  - {name: power, address: 0x10, type: i16}
+  - {name: meter_power, address: 0x10, type: i16, slave_id: 5}
+`
+
+// slaveIDs returns the slave addresses of all requests received
+func (f *fakeInverter) slaveIDs() map[uint8]bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res := make(map[uint8]bool)
+	for _, request := range f.requests {
+		res[request[0]] = true
+	}
+	return res
+}
+
+func TestReadProfileSlaveID(t *testing.T) {
+	solis, err := BuiltinProfile("solis_4g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meter, err := ParseProfileYAML([]byte(slaveProfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unset := func(*InverterLogger) context.Context { return context.Background() }
+	withSlave := func(id uint8) func(*InverterLogger) context.Context {
+		return func(*InverterLogger) context.Context { return WithSlaveID(context.Background(), id) }
+	}
+	setSlave := func(id uint8) func(*InverterLogger) context.Context {
+		return func(inv *InverterLogger) context.Context {
+			inv.SetSlaveID(id)
+			return context.Background()
+		}
+	}
+
+	tests := []struct {
+		name    string
+		profile *Profile
+		ctx     func(*InverterLogger) context.Context
+		want    []uint8
+	}{
+		{"builtin default", solis, unset, []uint8{1}},
+		{"builtin WithSlaveID", solis, withSlave(3), []uint8{3}},
+		{"builtin SetSlaveID", solis, setSlave(2), []uint8{2}},
+		{"profile default", meter, unset, []uint8{4, 5}},
+		{"profile SetSlaveID", meter, setSlave(2), []uint8{2, 5}},
+		{"profile WithSlaveID", meter, withSlave(3), []uint8{3, 5}},
+		{"WithSlaveID over SetSlaveID", meter, func(inv *InverterLogger) context.Context {
+			inv.SetSlaveID(2)
+			return WithSlaveID(context.Background(), 3)
+		}, []uint8{3, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, fake := newFakeInverter(t)
+
+			if _, err := inv.ReadProfileContext(tt.ctx(inv), tt.profile); err != nil {
+				t.Fatal(err)
+			}
+
+			got := fake.slaveIDs()
+			if len(got) != len(tt.want) {
+				t.Fatalf("slave ids %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Fatalf("slave ids %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterDefValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		def  RegisterDef
+		ok   bool
+	}{
+		{"u16 at last address", RegisterDef{Address: 0xFFFF}, true},
+		{"address out of range", RegisterDef{Address: 0x10000}, false},
+		{"negative address", RegisterDef{Address: -1}, false},
+		{"u32 at end", RegisterDef{Address: 0xFFFE, Type: TypeU32}, true},
+		{"u32 past end", RegisterDef{Address: 0xFFFF, Type: TypeU32}, false},
+		{"u64 past end", RegisterDef{Address: 0xFFFE, Type: TypeU64}, false},
+		{"ascii past end", RegisterDef{Address: 0xFFFE, Type: TypeASCII, Length: 8}, false},
+		{"ascii at end", RegisterDef{Address: 0xFFF8, Type: TypeASCII, Length: 8}, true},
+		{"addresses in range", RegisterDef{Address: 0xFFFF, Addresses: []int{0xFFFF, 0x10}, Type: TypeU32}, true},
+		{"addresses out of range", RegisterDef{Addresses: []int{0x10, 0x10000}, Type: TypeU32}, false},
+		{"length mismatch", RegisterDef{Address: 0x10, Type: TypeU32, Length: 3}, false},
+		{"mask on u32", RegisterDef{Address: 0x10, Type: TypeU32, Mask: 0xFF}, false},
+	}
+
+	for _, tt := range tests {
+		tt.def.Name = "entry"
+		if err := tt.def.validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+}
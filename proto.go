@@ -2,7 +2,9 @@ package solarman
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -16,26 +18,45 @@ import (
 // -----------------------------------------------------------------------------
 
 type InverterLogger struct {
-	LoggerAddress  string
-	LoggerSerialN  uint32
-	DebugEnable    bool
-	SequenceNumber uint32
-	Timeout        time.Duration
-	Meta           FrameMeta
-	mu             sync.Mutex
-	conn           net.Conn
-	connID         uint64
-	connNext       uint64
+	LoggerAddress    string
+	LoggerSerialN    uint32
+	DebugEnable      bool
+	DebugDissect     bool // log annotated frame breakdown instead of raw hex
+	SequenceNumber   uint32
+	Timeout          time.Duration
+	Meta             FrameMeta
+	SlaveID          uint8         // Modbus slave address, see SetSlaveID and WithSlaveID for per-call override
+	MaxReadRegisters int           // registers per read request, see SetReadChunking
+	ReadChunkDelay   time.Duration // pause between the requests of a split read
+	MaxReadGap       int           // unrequested registers ReadMany may read in between, see SetReadGap
+	VerifyWrites     bool          // read back written registers, see SetWriteVerify
+	WritePolicy      WritePolicy   // read-only, dry run and writable registers, see SetWritePolicy
+	Profile          *Profile      // register map used by ReadValues, see SetProfile
+	Retry            RetryPolicy
+	mu               sync.Mutex
+	dialer           Dialer
+	logger           Logger
+	conn             net.Conn
+	rbuf             []byte // bytes received but not consumed yet
+	lastMeta         ResponseMeta
+	maskUnsupported  map[uint8]bool // slave ids answering 0x16 with IllegalFunction
+	slaveIDSet       bool           // SlaveID set by SetSlaveID, wins over profile defaults
+	connID           uint64
+	connNext         uint64
 }
 
 func Init(address string, sn uint32, timeout int) *InverterLogger {
 	return &InverterLogger{
-		DebugEnable:    false,
-		SequenceNumber: 0,
-		LoggerAddress:  address,
-		LoggerSerialN:  sn,
-		Meta:           DefaultMeta,
-		Timeout:        time.Duration(timeout) * time.Second,
+		DebugEnable:      false,
+		SequenceNumber:   0,
+		LoggerAddress:    address,
+		LoggerSerialN:    sn,
+		Meta:             DefaultMeta,
+		SlaveID:          0x01,
+		MaxReadRegisters: MaxModbusRegisters,
+		MaxReadGap:       DefaultReadGap,
+		Retry:            DefaultRetryPolicy,
+		Timeout:          time.Duration(timeout) * time.Second,
 	}
 }
 
@@ -62,24 +83,35 @@ func (inv *InverterLogger) SetMeta(StartMarker byte, EndMarker byte, ReqControlC
 	inv.Meta.ResControlCode = ResControlCode
 }
 
+// SetDebug prints all records to stdout, shortcut for loggers without SetLogger
 func (inv *InverterLogger) SetDebug(enable bool) {
 	inv.mu.Lock()
 	defer inv.mu.Unlock()
 	inv.DebugEnable = enable
 }
 
+// SetDissect logs frames as a field-by-field breakdown (see Decode) instead of raw hex
+func (inv *InverterLogger) SetDissect(enable bool) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.DebugDissect = enable
+}
+
 /*
 	Modified to persistent connection - InverterLogger.conn
 */
 
-func (inv *InverterLogger) connect() error {
+func (inv *InverterLogger) connect(ctx context.Context) error {
 	if inv.conn != nil {
 		return nil
 	}
 
-	conn, err := net.DialTimeout("tcp", inv.LoggerAddress, inv.Timeout)
+	dialCtx, cancel := context.WithTimeout(ctx, inv.Timeout)
+	defer cancel()
+
+	conn, err := inv.getDialer().DialContext(dialCtx, "tcp", inv.LoggerAddress)
 	if err != nil {
-		return inv.error("net.DialTimeout", "conn failed", err)
+		return inv.error("net.Dial", "conn failed", markTimeout(err))
 	}
 
 	if tc, ok := conn.(*net.TCPConn); ok {
@@ -92,59 +124,130 @@ func (inv *InverterLogger) connect() error {
 	inv.connNext++
 	inv.connID = inv.connNext
 
-	inv.debugConn("OPEN", "")
+	inv.logConn("connection opened")
 
 	return nil
 }
 
-func (inv *InverterLogger) do(requestFrame []byte) ([]byte, error) {
-	if err := inv.connect(); err != nil {
-		return nil, err
+// deadline returns the earliest of inv.Timeout from now and the context deadline
+func (inv *InverterLogger) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(inv.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
 	}
+	return deadline
+}
 
-	_ = inv.conn.SetWriteDeadline(time.Now().Add(inv.Timeout))
-	_ = inv.conn.SetReadDeadline(time.Now().Add(inv.Timeout))
+// watchContext interrupts in-flight I/O on inv.conn when ctx is canceled.
+// The returned function stops watching and must be called before do returns.
+func (inv *InverterLogger) watchContext(ctx context.Context) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
 
-	inv.debug("net.requestFrame", "SENT", requestFrame)
+	conn := inv.conn
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			// deadline in the past unblocks pending Read/Write immediately
+			_ = conn.SetDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
 
-	if _, err := inv.conn.Write(requestFrame); err != nil {
-		inv.closeConn(inv.closeReason("write", err))
-		return nil, inv.error("conn.Write", "write failed", err)
+	return func() {
+		close(stop)
+		<-done
 	}
+}
 
-	reply := make([]byte, 512)
-	n, err := inv.conn.Read(reply)
-	if err != nil {
-		inv.closeConn(inv.closeReason("read", err))
-		return nil, inv.error("conn.Read", "read failed", err)
+// ioFailed closes the connection after a failed network operation,
+// reporting context cancellation in favour of the resulting I/O error
+func (inv *InverterLogger) ioFailed(ctx context.Context, point string, prefix string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		inv.closeConn(prefix + "_canceled")
+		return inv.error(point, prefix+" canceled", markTimeout(ctxErr))
 	}
 
-	reply = reply[:n]
-	inv.debug("net.reply", "RECD", reply)
-
-	return reply, nil
+	inv.closeConn(inv.closeReason(prefix, err))
+	return inv.error(point, prefix+" failed", markTimeout(err))
 }
 
-func (inv *InverterLogger) debugConn(event string, extra string) {
-	if !inv.DebugEnable {
-		return
+// do sends the request frame and waits for the matching response.
+// Unsolicited frames and late replies to previous requests are
+// discarded until the reply with the request sequence number arrives
+// or the deadline expires.
+func (inv *InverterLogger) do(ctx context.Context, request *Frame) (*Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, inv.error("do", "request canceled", markTimeout(err))
 	}
 
-	if inv.conn == nil {
-		inv.debug("net.conn", event, []byte("conn=nil "+extra), 1)
-		return
+	requestFrame, err := request.MarshalBinary(inv)
+	if err != nil {
+		return nil, inv.error("request.MarshalBinary", "frame marshal failed", err)
+	}
+
+	if err := inv.connect(ctx); err != nil {
+		return nil, err
 	}
 
-	laddr := inv.conn.LocalAddr().String()
-	raddr := inv.conn.RemoteAddr().String()
+	deadline := inv.deadline(ctx)
+	_ = inv.conn.SetWriteDeadline(deadline)
+	_ = inv.conn.SetReadDeadline(deadline)
+
+	stopWatch := inv.watchContext(ctx)
+	defer stopWatch()
 
-	msg := []byte(
-		fmt.Sprintf("id=%d %s -> %s %s",
-			inv.connID, laddr, raddr, extra,
-		),
-	)
+	inv.debugFrame("net.requestFrame", "frame sent", "tx", requestFrame)
 
-	inv.debug("net.conn", event, msg, 1)
+	if _, err := inv.conn.Write(requestFrame); err != nil {
+		return nil, inv.ioFailed(ctx, "conn.Write", "write", err)
+	}
+
+	for {
+		reply, err := inv.readFrame()
+		if err != nil {
+			return nil, inv.ioFailed(ctx, "conn.Read", "read", err)
+		}
+
+		inv.debugFrame("net.reply", "frame received", "rx", reply)
+
+		if controlCode := binary.LittleEndian.Uint16(reply[3:5]); controlCode != inv.Meta.ResControlCode {
+			inv.warn("net.reply", "unsolicited frame skipped", "control_code", fmt.Sprintf("0x%X", controlCode))
+			continue
+		}
+
+		// sequence is checked ahead of decoding, so a late reply to a previous
+		// request is skipped even when it is broken
+		if serialNumber := binary.BigEndian.Uint16(reply[5:7]); !request.answeredBy(serialNumber) {
+			inv.warn("net.reply", "stale frame skipped",
+				"seq", fmt.Sprintf("0x%02X", uint8(serialNumber>>8)),
+				"expected_seq", fmt.Sprintf("0x%02X", uint8(request.SerialNumber)))
+			continue
+		}
+
+		var response Frame
+		if err := response.UnmarshalBinary(inv, reply); err != nil {
+			return nil, inv.frameError("response.UnmarshalBinary", "frame unmarshal failed", reply, err)
+		}
+
+		return &response, nil
+	}
+}
+
+func (inv *InverterLogger) logConn(event string, args ...any) {
+	if inv.conn != nil {
+		args = append([]any{
+			"local_addr", inv.conn.LocalAddr().String(),
+			"remote_addr", inv.conn.RemoteAddr().String(),
+		}, args...)
+	}
+
+	inv.info("net.conn", event, args...)
 }
 
 func (inv *InverterLogger) closeConn(reason string) {
@@ -152,10 +255,11 @@ func (inv *InverterLogger) closeConn(reason string) {
 		return
 	}
 
-	inv.debugConn("CLOSE", "reason="+reason)
+	inv.logConn("connection closed", "reason", reason)
 
 	_ = inv.conn.Close()
 	inv.conn = nil
+	inv.rbuf = nil
 	inv.connID = 0
 }
 
@@ -179,79 +283,227 @@ Public methods using InverterLogger.conn
 */
 
 func (inv *InverterLogger) Read(startReg, regCnt int) (map[int]uint16, error) {
+	return inv.ReadContext(context.Background(), startReg, regCnt)
+}
+
+// ReadContext reads regCnt holding registers starting from startReg,
+// split into several requests of at most MaxReadRegisters each.
+// The context deadline limits the exchange in addition to inv.Timeout,
+// cancellation aborts it and closes the connection.
+func (inv *InverterLogger) ReadContext(ctx context.Context, startReg, regCnt int) (map[int]uint16, error) {
 	inv.mu.Lock()
 	defer inv.mu.Unlock()
 
-	requestPayload, _ := inv.NewReadRequestPayload(uint16(startReg), uint16(regCnt)).MarshalBinary(inv)
-	requestFrame, _ := inv.NewFrame(inv.LoggerSerialN, requestPayload).MarshalBinary(inv)
-
-	reply, err := inv.do(requestFrame)
+	res, err := inv.readChunked(ctx, FuncReadHoldingRegisters, startReg, regCnt)
 	if err != nil {
-		return nil, inv.error("Read.do", "request failed", err)
+		return nil, inv.error("Read.readChunked", "request failed", err)
 	}
 
-	var responseFrame Frame
-	if err := responseFrame.UnmarshalBinary(inv, reply); err != nil {
-		return nil, inv.error("Read.responseFrame.UnmarshalBinary", "frame unmarshal failed", err)
-	}
+	return res, nil
+}
 
-	var responsePayload ResponsePayload
-	if err := responsePayload.UnmarshalBinary(inv, responseFrame.Payload); err != nil {
-		return nil, inv.error("Read.responsePayload.UnmarshalBinary", "payload unmarshal failed", err)
+// ReadInput reads regCnt input registers (Modbus function 0x04) starting from startReg
+func (inv *InverterLogger) ReadInput(startReg, regCnt int) (map[int]uint16, error) {
+	return inv.ReadInputContext(context.Background(), startReg, regCnt)
+}
+
+func (inv *InverterLogger) ReadInputContext(ctx context.Context, startReg, regCnt int) (map[int]uint16, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	res, err := inv.readChunked(ctx, FuncReadInputRegisters, startReg, regCnt)
+	if err != nil {
+		return nil, inv.error("ReadInput.readChunked", "request failed", err)
 	}
 
-	inv.debug("Read.responsePayload.Value", "RECD", responsePayload.Value)
+	return res, nil
+}
+
+// readRegisters reads holding (0x03) or input (0x04) registers, inv.mu must be held
+func (inv *InverterLogger) readRegisters(ctx context.Context, functionCode uint8, startReg, regCnt int) (map[int]uint16, error) {
+	request := inv.NewReadRequestPayload(uint16(startReg), uint16(regCnt))
+	request.FunctionCode = functionCode
+	request.DeviceAddress = inv.slaveID(ctx)
 
-	buf := bytes.NewBuffer(responsePayload.Value)
+	requestPayload, err := request.MarshalBinary(inv)
+	if err != nil {
+		return nil, inv.error("readRegisters.requestPayload", "payload marshal failed", err)
+	}
 
 	res := make(map[int]uint16)
-	for i := 0; i < regCnt; i++ {
-		var val uint16
-		if err := binary.Read(buf, binary.BigEndian, &val); err != nil {
-			return nil, inv.error("Read.responsePayload.binary.Read", "read payload to buf failed", err)
+
+	err = inv.exchange(ctx, requestPayload, false, func(responseFrame *Frame) error {
+		var responsePayload ResponsePayload
+		modbus, err := inv.unmarshalHeader(&responsePayload, responseFrame.Payload)
+		if err != nil {
+			return inv.frameError("readRegisters.responsePayload.unmarshalHeader", "payload unmarshal failed", responseFrame.raw, err)
+		}
+
+		if err := responsePayload.unmarshalModbus(inv, modbus); err != nil {
+			return inv.frameError("readRegisters.responsePayload.UnmarshalBinary", "payload unmarshal failed", responseFrame.raw, err)
 		}
-		res[startReg+i] = val
+
+		if err := responsePayload.answers(request.DeviceAddress, functionCode); err != nil {
+			return inv.frameError("readRegisters.responsePayload.answers", "unexpected response", responseFrame.raw, err)
+		}
+
+		inv.debug("readRegisters.responsePayload.Value", "registers received",
+			"function", functionCode, "start", startReg, "count", regCnt, "hex", hex.EncodeToString(responsePayload.Value))
+
+		buf := bytes.NewBuffer(responsePayload.Value)
+
+		for i := 0; i < regCnt; i++ {
+			var val uint16
+			if err := binary.Read(buf, binary.BigEndian, &val); err != nil {
+				return inv.frameError("readRegisters.responsePayload.binary.Read", "read payload to buf failed", responseFrame.raw, shortFrame(err))
+			}
+			res[startReg+i] = val
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, inv.error("readRegisters.exchange", "request failed", err)
 	}
 
 	return res, nil
 }
 
 func (inv *InverterLogger) Write(startRegister int, values []int) (int, int, error) {
+	return inv.WriteContext(context.Background(), startRegister, values)
+}
+
+// WriteContext writes values to consecutive registers starting from startRegister.
+// The context deadline limits the exchange in addition to inv.Timeout,
+// cancellation aborts it and closes the connection.
+func (inv *InverterLogger) WriteContext(ctx context.Context, startRegister int, values []int) (int, int, error) {
 	inv.mu.Lock()
 	defer inv.mu.Unlock()
 
+	count, start, err := inv.writeRegisters(ctx, startRegister, values)
+	if err != nil {
+		return 0, 0, inv.error("Write.writeRegisters", "request failed", err)
+	}
+
+	if inv.VerifyWrites && !inv.WritePolicy.DryRun {
+		if err := inv.verifyRegisters(ctx, startRegister, values); err != nil {
+			return 0, 0, inv.error("Write.verifyRegisters", "verification failed", err)
+		}
+	}
+
+	return count, start, nil
+}
+
+// writeRegisters writes values with Modbus function 0x10, inv.mu must be held
+func (inv *InverterLogger) writeRegisters(ctx context.Context, startRegister int, values []int) (int, int, error) {
 	numRegisters := len(values)
 	registerValues := make([]uint16, numRegisters)
 	for offset, value := range values {
 		registerValues[offset] = uint16(value)
 	}
 
-	writePayload, err := inv.NewWriteRequestPayload(uint16(startRegister), registerValues).MarshalBinary()
+	if err := inv.WritePolicy.checkWrite(startRegister, registerValues); err != nil {
+		return 0, 0, inv.error("writeRegisters.checkWrite", "write denied", err)
+	}
+
+	request := inv.NewWriteRequestPayload(uint16(startRegister), registerValues)
+	request.DeviceAddress = inv.slaveID(ctx)
+
+	writePayload, err := request.MarshalBinary()
+	if err != nil {
+		return 0, 0, inv.error("writeRegisters.writePayload", "payload marshal failed", err)
+	}
+
+	if skip, err := inv.dryRun("writeRegisters.dryRun", writePayload); skip {
+		return len(values) * 2, startRegister, err
+	}
+
+	var count, start int
+
+	err = inv.exchange(ctx, writePayload, true, func(responseFrame *Frame) error {
+		var header ResponsePayload
+		modbus, err := inv.unmarshalHeader(&header, responseFrame.Payload)
+		if err != nil {
+			return inv.frameError("writeRegisters.responsePayload.unmarshalHeader", "payload unmarshal failed", responseFrame.raw, err)
+		}
+
+		count, start, err = inv.parseWriteResponse(modbus, request.DeviceAddress, values)
+		if err = header.checkStatus(modbus, err); err != nil {
+			return inv.frameError("writeRegisters.parseWriteResponse", "payload unmarshal failed", responseFrame.raw, err)
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, 0, inv.error("Write.writePayload", "payload marshal failed", err)
+		return 0, 0, inv.error("writeRegisters.exchange", "request failed", err)
 	}
 
-	writeFrame, err := inv.NewFrame(inv.LoggerSerialN, writePayload).MarshalBinary(inv)
+	return count, start, nil
+}
+
+// WriteSingle writes one register with Modbus function 0x06,
+// for firmwares rejecting 0x10 for certain settings
+func (inv *InverterLogger) WriteSingle(register int, value int) error {
+	return inv.WriteSingleContext(context.Background(), register, value)
+}
+
+func (inv *InverterLogger) WriteSingleContext(ctx context.Context, register int, value int) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if err := inv.WritePolicy.checkWrite(register, []uint16{uint16(value)}); err != nil {
+		return inv.error("WriteSingle.checkWrite", "write denied", err)
+	}
+
+	request := inv.NewWriteSingleRequestPayload(uint16(register), uint16(value))
+	request.DeviceAddress = inv.slaveID(ctx)
+
+	writePayload, err := request.MarshalBinary()
 	if err != nil {
-		return 0, 0, inv.error("Write.writeFrame", "frame marshal failed", err)
+		return inv.error("WriteSingle.writePayload", "payload marshal failed", err)
 	}
 
-	reply, err := inv.do(writeFrame)
+	err = inv.writeEcho(ctx, writePayload, request.DeviceAddress, request.FunctionCode, request.RegisterAddress, request.RegisterValue)
 	if err != nil {
-		return 0, 0, inv.error("Write.do", "request failed", err)
+		return inv.error("WriteSingle.writeEcho", "request failed", err)
 	}
 
-	var responseFrame Frame
-	if err := responseFrame.UnmarshalBinary(inv, reply); err != nil {
-		return 0, 0, inv.error("Write.responseFrame.UnmarshalBinary", "frame unmarshal failed", err)
+	if inv.VerifyWrites && !inv.WritePolicy.DryRun {
+		if err := inv.verifyRegisters(ctx, register, []int{value}); err != nil {
+			return inv.error("WriteSingle.verifyRegisters", "verification failed", err)
+		}
 	}
 
-	count, start, err := inv.parseWriteResponse(responseFrame.Payload, values)
+	return nil
+}
+
+// writeEcho sends a write request answered by an echo (0x05, 0x06, 0x0F)
+// and checks the echo against the expected address and value, inv.mu must be held
+func (inv *InverterLogger) writeEcho(ctx context.Context, writePayload []byte, deviceAddress, functionCode uint8, address, value uint16) error {
+	if skip, err := inv.dryRun("writeEcho.dryRun", writePayload); skip {
+		return err
+	}
+
+	err := inv.exchange(ctx, writePayload, true, func(responseFrame *Frame) error {
+		var header ResponsePayload
+		modbus, err := inv.unmarshalHeader(&header, responseFrame.Payload)
+		if err != nil {
+			return inv.frameError("writeEcho.responsePayload.unmarshalHeader", "payload unmarshal failed", responseFrame.raw, err)
+		}
+
+		echo, err := parseEchoResponse(modbus)
+		if err == nil {
+			err = echo.matches(deviceAddress, functionCode, address, value)
+		}
+		if err = header.checkStatus(modbus, err); err != nil {
+			return inv.frameError("writeEcho.parseEchoResponse", "payload unmarshal failed", responseFrame.raw, err)
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, 0, inv.error("Write.parseWriteResponse", "payload unmarshal failed", err)
+		return inv.error("writeEcho.exchange", "request failed", err)
 	}
 
-	return count, start, nil
+	return nil
 }
 
 func (inv *InverterLogger) Close() error {
@@ -260,9 +512,7 @@ func (inv *InverterLogger) Close() error {
 
 	if inv.conn == nil {
 		// idempotent close
-		if inv.DebugEnable {
-			inv.debug("net.conn", "CLOSE", []byte("id=0 conn=nil reason=manual"))
-		}
+		inv.logConn("connection closed", "reason", "manual")
 		return nil
 	}
 
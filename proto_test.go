@@ -0,0 +1,79 @@
+package solarman
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// badChecksum returns a copy of frame with a broken V5 checksum
+func badChecksum(frame []byte) []byte {
+	frame = append([]byte(nil), frame...)
+	frame[len(frame)-2] ^= 0xFF
+	return frame
+}
+
+// withControlCode returns a copy of frame with another control code and a valid checksum
+func withControlCode(frame []byte, controlCode uint16) []byte {
+	frame = append([]byte(nil), frame...)
+	binary.LittleEndian.PutUint16(frame[3:5], controlCode)
+	frame[len(frame)-2] = calcCheckSum8(frame[1 : len(frame)-2])
+	return frame
+}
+
+func TestDoSkipsUnmatchedFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		before func(seq uint8) [][]byte
+	}{
+		{"stale sequence", func(seq uint8) [][]byte {
+			return [][]byte{responseFrame(seq-1, readReply(9, 9))}
+		}},
+		{"stale sequence with broken checksum", func(seq uint8) [][]byte {
+			return [][]byte{badChecksum(responseFrame(seq-1, readReply(9, 9)))}
+		}},
+		{"heartbeat control code", func(seq uint8) [][]byte {
+			return [][]byte{withControlCode(responseFrame(seq, readReply(9, 9)), 0x4710)}
+		}},
+		{"garbage and stale frames", func(seq uint8) [][]byte {
+			return [][]byte{{0x00, 0xA5}, responseFrame(seq-2, readReply(9)), responseFrame(seq+1, readReply(9))}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := newFakeLogger(t, func(request []byte) [][]byte {
+				seq := requestSeq(request)
+				return append(tt.before(seq), responseFrame(seq, readReply(0x0102, 0x0304)))
+			})
+
+			res, err := inv.Read(0x10, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res[0x10] != 0x0102 || res[0x11] != 0x0304 {
+				t.Fatalf("got %v", res)
+			}
+		})
+	}
+}
+
+func TestDoMatchingFrameBroken(t *testing.T) {
+	inv := newFakeLogger(t, func(request []byte) [][]byte {
+		return [][]byte{badChecksum(responseFrame(requestSeq(request), readReply(1, 2)))}
+	})
+
+	if _, err := inv.Read(0, 2); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("got %v, want ErrChecksum", err)
+	}
+}
+
+func TestDoNoMatchingFrame(t *testing.T) {
+	inv := newFakeLogger(t, func(request []byte) [][]byte {
+		return [][]byte{responseFrame(requestSeq(request)+1, readReply(1, 2))}
+	})
+
+	if _, err := inv.Read(0, 2); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("got %v, want ErrTimeout", err)
+	}
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // -----------------------------------------------------------------------------
-// Register read request (Modbus function 0x03)
+// Register read request (Modbus function 0x03, 0x04 for input registers)
 // -----------------------------------------------------------------------------
 
 type ReadRequestPayload struct {
@@ -17,8 +17,8 @@ type ReadRequestPayload struct {
 	PowerOnTime  uint32
 	OffsetTime   uint32
 
-	DeviceAddress uint8  // device address, usually 0x01
-	FunctionCode  uint8  // 0x03 – reading registers
+	DeviceAddress uint8  // Modbus slave address, InverterLogger.SlaveID
+	FunctionCode  uint8  // 0x03 – reading holding registers, 0x04 – input registers
 	StartReg      uint16 // address of the first register
 	RegCount      uint16 // number of registers to read
 }
@@ -30,8 +30,8 @@ func (inv *InverterLogger) NewReadRequestPayload(startReg, regCount uint16) *Rea
 		DeliveryTime:  0x00000000,
 		PowerOnTime:   0x00000000,
 		OffsetTime:    0x00000000,
-		DeviceAddress: 0x01,
-		FunctionCode:  0x03,
+		DeviceAddress: inv.SlaveID,
+		FunctionCode:  FuncReadHoldingRegisters,
 		StartReg:      startReg,
 		RegCount:      regCount,
 	}
@@ -110,25 +110,40 @@ type ResponsePayload struct {
 
 // full frame unmarshaling
 func (r *ResponsePayload) UnmarshalBinary(inv *InverterLogger, data []byte) error {
+	modbus, err := r.unmarshalHeader(data)
+	if err != nil {
+		return err
+	}
+
+	return r.unmarshalModbus(inv, modbus)
+}
+
+// Modbus part unmarshaling, errors are mapped onto the logger status
+func (r *ResponsePayload) unmarshalModbus(inv *InverterLogger, modbus []byte) error {
+	return r.checkStatus(modbus, r.unmarshalBusinessPayload(inv, modbus))
+}
+
+// header unmarshaling, returns the remaining Modbus part
+func (r *ResponsePayload) unmarshalHeader(data []byte) ([]byte, error) {
 	buf := bytes.NewBuffer(data)
 
 	if err := binary.Read(buf, binary.LittleEndian, &r.FrameType); err != nil {
-		return fmt.Errorf("r.FrameType binary read failed - %w", err)
+		return nil, fmt.Errorf("r.FrameType binary read failed - %w", shortFrame(err))
 	}
 	if err := binary.Read(buf, binary.LittleEndian, &r.StatusCode); err != nil {
-		return fmt.Errorf("r.StatusCode binary read failed - %w", err)
+		return nil, fmt.Errorf("r.StatusCode binary read failed - %w", shortFrame(err))
 	}
 	if err := binary.Read(buf, binary.LittleEndian, &r.DeliveryTime); err != nil {
-		return fmt.Errorf("r.DeliveryTime binary read failed - %w", err)
+		return nil, fmt.Errorf("r.DeliveryTime binary read failed - %w", shortFrame(err))
 	}
 	if err := binary.Read(buf, binary.LittleEndian, &r.PowerOnTime); err != nil {
-		return fmt.Errorf("r.PowerOnTime binary read failed - %w", err)
+		return nil, fmt.Errorf("r.PowerOnTime binary read failed - %w", shortFrame(err))
 	}
 	if err := binary.Read(buf, binary.LittleEndian, &r.OffsetTime); err != nil {
-		return fmt.Errorf("r.OffsetTime binary read failed - %w", err)
+		return nil, fmt.Errorf("r.OffsetTime binary read failed - %w", shortFrame(err))
 	}
 
-	return r.unmarshalBusinessPayload(inv, buf.Bytes())
+	return buf.Bytes(), nil
 }
 
 // payload unmarshaling
@@ -136,13 +151,21 @@ func (r *ResponsePayload) unmarshalBusinessPayload(inv *InverterLogger, data []b
 	buf := bytes.NewBuffer(data)
 
 	if err := binary.Read(buf, binary.LittleEndian, &r.DeviceAddress); err != nil {
-		return fmt.Errorf("r.DeviceAddress binary read failed - %w", err)
+		return fmt.Errorf("r.DeviceAddress binary read failed - %w", shortFrame(err))
 	}
 	if err := binary.Read(buf, binary.LittleEndian, &r.FunctionCode); err != nil {
-		return fmt.Errorf("r.FunctionCode binary read failed - %w", err)
+		return fmt.Errorf("r.FunctionCode binary read failed - %w", shortFrame(err))
+	}
+
+	if isException(r.FunctionCode) {
+		exception, err := unmarshalException(data)
+		if err != nil {
+			return fmt.Errorf("exception response unmarshal failed - %w", err)
+		}
+		return exception
 	}
 	if err := binary.Read(buf, binary.LittleEndian, &r.ValueLength); err != nil {
-		return fmt.Errorf("r.ValueLength binary read failed - %w", err)
+		return fmt.Errorf("r.ValueLength binary read failed - %w", shortFrame(err))
 	}
 
 	r.Value = make([]byte, r.ValueLength)
@@ -150,14 +173,14 @@ func (r *ResponsePayload) unmarshalBusinessPayload(inv *InverterLogger, data []b
 	n, err := buf.Read(r.Value)
 
 	if err != nil {
-		return fmt.Errorf("r.Value buf read failed - %w", err)
+		return fmt.Errorf("r.Value buf read failed - %w", shortFrame(err))
 	} else if n != int(r.ValueLength) {
-		return fmt.Errorf("%d bytes read of expected %d bytes", n, r.ValueLength)
+		return fmt.Errorf("%w: %d bytes read of expected %d bytes", ErrShortFrame, n, r.ValueLength)
 	}
 
 	var crc uint16
 	if err := binary.Read(buf, binary.LittleEndian, &crc); err != nil {
-		return fmt.Errorf("CRC binary read failed - %w", err)
+		return fmt.Errorf("CRC binary read failed - %w", shortFrame(err))
 	}
 
 	// Compute expected CRC (excluding last 2 bytes)
@@ -165,7 +188,7 @@ func (r *ResponsePayload) unmarshalBusinessPayload(inv *InverterLogger, data []b
 
 	// Compare CRC values
 	if crc != expectedCRC {
-		return fmt.Errorf("CRC mismatch: expected 0x%X, got 0x%X", expectedCRC, crc)
+		return fmt.Errorf("%w: expected 0x%X, got 0x%X", ErrCRC, expectedCRC, crc)
 	}
 
 	// Skip two bytes that can be null values
@@ -177,3 +200,12 @@ func (r *ResponsePayload) unmarshalBusinessPayload(inv *InverterLogger, data []b
 
 	return nil
 }
+
+// answers checks the reply comes from the requested slave and function
+func (r *ResponsePayload) answers(deviceAddress uint8, functionCode uint8) error {
+	if r.DeviceAddress != deviceAddress || r.FunctionCode != functionCode {
+		return fmt.Errorf("%w: deviceAddress %d, functionCode 0x%02X, expected %d, 0x%02X",
+			ErrUnexpectedResponse, r.DeviceAddress, r.FunctionCode, deviceAddress, functionCode)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package solarman
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// -----------------------------------------------------------------------------
+// Stream-aware V5 frame reader
+// -----------------------------------------------------------------------------
+
+// V5 frame layout:
+// 1 byte:  start marker
+// 2 bytes: payload length (Little Endian)
+// 2 bytes: control code
+// 2 bytes: serial (sequence) number
+// 4 bytes: logger serial number
+// N bytes: payload
+// 1 byte:  checksum
+// 1 byte:  end marker
+
+const (
+	frameHeaderLen  = 11
+	frameTrailerLen = 2
+
+	// Upper bound for a sane payload length, anything above is treated
+	// as a false start marker found inside garbage
+	maxPayloadLength = 1024
+
+	readChunkSize = 1024
+)
+
+// readFrame returns exactly one V5 frame read from inv.conn.
+// Bytes in front of the start marker are dropped, bytes following
+// the frame are kept in inv.rbuf for the next call.
+func (inv *InverterLogger) readFrame() ([]byte, error) {
+	chunk := make([]byte, readChunkSize)
+
+	for {
+		if frame, ok := inv.extractFrame(); ok {
+			return frame, nil
+		}
+
+		n, err := inv.conn.Read(chunk)
+		if n > 0 {
+			inv.rbuf = append(inv.rbuf, chunk[:n]...)
+		}
+
+		if err != nil {
+			// frame may be completed by the last bytes received along with the error
+			if frame, ok := inv.extractFrame(); ok {
+				return frame, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// extractFrame cuts the first complete frame out of inv.rbuf,
+// resynchronising on the start marker if needed
+func (inv *InverterLogger) extractFrame() ([]byte, bool) {
+	for {
+		start := bytes.IndexByte(inv.rbuf, inv.Meta.StartMarker)
+		if start < 0 {
+			if len(inv.rbuf) > 0 {
+				inv.debugFrame("net.readFrame", "garbage dropped", "drop", inv.rbuf)
+			}
+			inv.rbuf = inv.rbuf[:0]
+			return nil, false
+		}
+
+		if start > 0 {
+			inv.debugFrame("net.readFrame", "garbage dropped", "drop", inv.rbuf[:start])
+			inv.rbuf = inv.rbuf[start:]
+		}
+
+		if len(inv.rbuf) < 3 {
+			return nil, false
+		}
+
+		payloadLength := int(binary.LittleEndian.Uint16(inv.rbuf[1:3]))
+		if payloadLength > maxPayloadLength {
+			// not a real frame start, skip the marker and look for the next one
+			inv.debugFrame("net.readFrame", "false start marker dropped", "drop", inv.rbuf[:1])
+			inv.rbuf = inv.rbuf[1:]
+			continue
+		}
+
+		frameLength := frameHeaderLen + payloadLength + frameTrailerLen
+		if len(inv.rbuf) < frameLength {
+			return nil, false
+		}
+
+		if inv.rbuf[frameLength-1] != inv.Meta.EndMarker {
+			inv.debugFrame("net.readFrame", "false start marker dropped", "drop", inv.rbuf[:1])
+			inv.rbuf = inv.rbuf[1:]
+			continue
+		}
+
+		frame := make([]byte, frameLength)
+		copy(frame, inv.rbuf[:frameLength])
+		inv.rbuf = inv.rbuf[frameLength:]
+
+		return frame, true
+	}
+}
@@ -0,0 +1,100 @@
+package solarman
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+// pipeLogger returns an InverterLogger reading the given chunks from net.Pipe,
+// the writing end is closed after the last chunk
+func pipeLogger(t *testing.T, chunks ...[]byte) *InverterLogger {
+	t.Helper()
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		for _, chunk := range chunks {
+			if _, err := server.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() { _ = client.Close() })
+
+	inv := Init("pipe", testLoggerSN, 1)
+	inv.conn = client
+	return inv
+}
+
+func concat(chunks ...[]byte) []byte {
+	return bytes.Join(chunks, nil)
+}
+
+func TestReadFrame(t *testing.T) {
+	frame := responseFrame(0x01, readReply(1, 2, 3))
+	second := responseFrame(0x02, readReply(4))
+
+	oversize := []byte{DefaultMeta.StartMarker, 0xFF, 0xFF}
+	// plausible length, but the end marker is not where it points to
+	falseStart := []byte{DefaultMeta.StartMarker, 0x01, 0x00}
+
+	tests := []struct {
+		name   string
+		chunks [][]byte
+		want   [][]byte
+	}{
+		{"whole frame", [][]byte{frame}, [][]byte{frame}},
+		{"split frame", [][]byte{frame[:1], frame[1:6], frame[6:20], frame[20:]}, [][]byte{frame}},
+		{"garbage prefix", [][]byte{{0x00, 0x33, 0xFF}, frame}, [][]byte{frame}},
+		{"garbage prefix in same chunk", [][]byte{concat([]byte{0x00, 0x33}, frame)}, [][]byte{frame}},
+		{"oversize length", [][]byte{concat(oversize, frame)}, [][]byte{frame}},
+		{"bad end marker", [][]byte{concat(falseStart, frame)}, [][]byte{frame}},
+		{"truncated frame followed by frame", [][]byte{concat(frame[:10], second)}, [][]byte{second}},
+		{"two frames in one chunk", [][]byte{concat(frame, second)}, [][]byte{frame, second}},
+		{"frames across chunks", [][]byte{concat(frame, second[:4]), second[4:]}, [][]byte{frame, second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := pipeLogger(t, tt.chunks...)
+
+			for i, want := range tt.want {
+				got, err := inv.readFrame()
+				if err != nil {
+					t.Fatalf("frame %d: %v", i, err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("frame %d:\n got % X\nwant % X", i, got, want)
+				}
+			}
+
+			if _, err := inv.readFrame(); !errors.Is(err, io.EOF) {
+				t.Fatalf("after last frame: got %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestReadFrameTruncated(t *testing.T) {
+	frame := responseFrame(0x01, readReply(1, 2, 3))
+
+	inv := pipeLogger(t, frame[:len(frame)-3])
+
+	if got, err := inv.readFrame(); !errors.Is(err, io.EOF) {
+		t.Fatalf("got % X, %v, want io.EOF", got, err)
+	}
+}
+
+func TestReadFrameDropsGarbage(t *testing.T) {
+	inv := pipeLogger(t, []byte{0x00, 0x01, 0x02}, []byte{0x03})
+
+	if _, err := inv.readFrame(); !errors.Is(err, io.EOF) {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+	if len(inv.rbuf) != 0 {
+		t.Fatalf("garbage kept in buffer: % X", inv.rbuf)
+	}
+}
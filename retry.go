@@ -0,0 +1,134 @@
+package solarman
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Retry policy
+// -----------------------------------------------------------------------------
+
+// RetryClass selects which errors are worth another attempt
+type RetryClass uint8
+
+const (
+	RetryTimeout RetryClass = 1 << iota // network deadline exceeded or no reply from inverter on RS485
+	RetryEOF                            // connection closed by the logger
+	RetryCRC                            // corrupted reply: V5 checksum or Modbus CRC mismatch, truncated frame
+)
+
+type RetryPolicy struct {
+	MaxAttempts int           // total number of attempts, including the first one
+	BaseDelay   time.Duration // delay before the second attempt, doubled for every next one
+	MaxDelay    time.Duration // upper bound for the delay between attempts
+	Jitter      float64       // random deviation of each delay, fraction from 0 to 1
+	RetryOn     RetryClass    // error classes to retry
+	RetryWrites bool          // writes are not idempotent and retried only when enabled
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Jitter:      0.2,
+	RetryOn:     RetryTimeout | RetryEOF | RetryCRC,
+	RetryWrites: false,
+}
+
+// NoRetry disables retries altogether
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+func (inv *InverterLogger) SetRetryPolicy(policy RetryPolicy) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.Retry = policy
+}
+
+// class maps an error to its RetryClass, 0 if the error is not retryable at all
+func (p RetryPolicy) class(err error) RetryClass {
+	var ne net.Error
+	switch {
+	case errors.Is(err, ErrTimeout), errors.Is(err, ErrNoInverterReply), errors.As(err, &ne) && ne.Timeout():
+		return RetryTimeout
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return RetryEOF
+	case errors.Is(err, ErrChecksum), errors.Is(err, ErrCRC), errors.Is(err, ErrShortFrame):
+		return RetryCRC
+	}
+	return 0
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	return p.RetryOn&p.class(err) != 0
+}
+
+// backoff returns the delay after the given (1-based) failed attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		delay += time.Duration(float64(delay) * p.Jitter * (2*rand.Float64() - 1))
+	}
+
+	return delay
+}
+
+// exchange sends the payload in a new frame and passes the matching response
+// to handle, retrying failed attempts according to inv.Retry.
+// Every attempt gets a fresh sequence number, so late replies to a failed
+// attempt are dropped by do. A broken connection is reopened by do as well.
+func (inv *InverterLogger) exchange(ctx context.Context, payload []byte, write bool, handle func(response *Frame) error) error {
+	policy := inv.Retry
+
+	attempts := policy.MaxAttempts
+	if attempts < 1 || (write && !policy.RetryWrites) {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		response, err := inv.do(ctx, inv.NewFrame(inv.LoggerSerialN, payload))
+		if err == nil {
+			err = handle(response)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= attempts || ctx.Err() != nil || !policy.retryable(err) {
+			inv.logError("exchange", "request failed", err, "attempt", attempt)
+			return err
+		}
+
+		delay := policy.backoff(attempt)
+		inv.warn("exchange", "request failed, retrying", "error", err, "attempt", attempt, "max_attempts", attempts, "delay", delay)
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return inv.error("exchange", "retry canceled", markTimeout(err))
+		}
+	}
+}
+
+// sleepContext pauses for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
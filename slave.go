@@ -0,0 +1,43 @@
+package solarman
+
+import (
+	"context"
+)
+
+// -----------------------------------------------------------------------------
+// Modbus slave address
+// -----------------------------------------------------------------------------
+
+type slaveIDKey struct{}
+
+// WithSlaveID returns a context addressing the Modbus slave id instead of
+// InverterLogger.SlaveID, for devices sharing one RS485 bus behind a logger:
+//
+//	meter := solarman.WithSlaveID(ctx, 2)
+//	data, err := deye.ReadContext(meter, 0x00, 10)
+func WithSlaveID(ctx context.Context, id uint8) context.Context {
+	return context.WithValue(ctx, slaveIDKey{}, id)
+}
+
+// SetSlaveID sets the default Modbus slave address for all requests,
+// it wins over the slave address of a Profile
+func (inv *InverterLogger) SetSlaveID(id uint8) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.SlaveID = id
+	inv.slaveIDSet = true
+}
+
+// slaveID returns the per-call override from ctx or inv.SlaveID
+func (inv *InverterLogger) slaveID(ctx context.Context) uint8 {
+	if id, ok := slaveIDOverride(ctx); ok {
+		return id
+	}
+	return inv.SlaveID
+}
+
+// slaveIDOverride returns the slave address set by WithSlaveID
+func slaveIDOverride(ctx context.Context) (uint8, bool) {
+	id, ok := ctx.Value(slaveIDKey{}).(uint8)
+	return id, ok
+}
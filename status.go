@@ -0,0 +1,103 @@
+package solarman
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// V5 response status and header timing fields
+// -----------------------------------------------------------------------------
+
+// LoggerStatus is the status byte of the V5 response payload
+type LoggerStatus uint8
+
+const StatusOK LoggerStatus = 0x01
+
+func (s LoggerStatus) String() string {
+	if s == StatusOK {
+		return "OK"
+	}
+	return fmt.Sprintf("0x%02X", uint8(s))
+}
+
+// StatusError is returned when the logger answered, but carried no valid
+// reply of the inverter: the Modbus frame is missing, or is broken and the
+// status byte is not StatusOK. It matches ErrNoInverterReply.
+type StatusError struct {
+	Status LoggerStatus // status byte of the V5 response
+	Detail error        // Modbus decoding error caused by the missing reply, may be nil
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("logger status %s: %v", e.Status, ErrNoInverterReply)
+	if e.Detail != nil {
+		msg += " (" + e.Detail.Error() + ")"
+	}
+	return msg
+}
+
+func (e *StatusError) Unwrap() error {
+	return ErrNoInverterReply
+}
+
+// checkStatus maps the logger status onto the result of Modbus decoding
+func (r *ResponsePayload) checkStatus(modbus []byte, err error) error {
+	status := LoggerStatus(r.StatusCode)
+
+	if len(modbus) == 0 {
+		return &StatusError{Status: status}
+	}
+
+	if err == nil || status == StatusOK {
+		return err
+	}
+
+	// a proper exception is a reply of the inverter regardless of the status
+	var exception *ModbusException
+	if errors.As(err, &exception) {
+		return err
+	}
+
+	return &StatusError{Status: status, Detail: err}
+}
+
+// ResponseMeta exposes the V5 response header
+type ResponseMeta struct {
+	Status           LoggerStatus
+	TotalWorkingTime time.Duration // logger total working time (DeliveryTime field)
+	PowerOnTime      time.Duration // logger uptime since the last power on
+	OffsetTime       uint32        // raw offset field, Unix time of the last power on
+	LoggerTime       time.Time     // logger clock, OffsetTime + PowerOnTime
+}
+
+func (r *ResponsePayload) Meta() ResponseMeta {
+	return ResponseMeta{
+		Status:           LoggerStatus(r.StatusCode),
+		TotalWorkingTime: time.Duration(r.DeliveryTime) * time.Second,
+		PowerOnTime:      time.Duration(r.PowerOnTime) * time.Second,
+		OffsetTime:       r.OffsetTime,
+		LoggerTime:       time.Unix(int64(r.OffsetTime)+int64(r.PowerOnTime), 0),
+	}
+}
+
+// unmarshalHeader parses the V5 response header into r and keeps it for
+// LastResponseMeta, a broken header clears it. inv.mu must be held
+func (inv *InverterLogger) unmarshalHeader(r *ResponsePayload, data []byte) ([]byte, error) {
+	modbus, err := r.unmarshalHeader(data)
+	if err != nil {
+		inv.lastMeta = ResponseMeta{}
+		return nil, err
+	}
+
+	inv.lastMeta = r.Meta()
+	return modbus, nil
+}
+
+// LastResponseMeta returns the header of the last response received from the logger
+func (inv *InverterLogger) LastResponseMeta() ResponseMeta {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.lastMeta
+}
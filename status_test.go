@@ -0,0 +1,38 @@
+package solarman
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestLastResponseMeta(t *testing.T) {
+	broken := false
+	inv := newFakeLogger(t, func(request []byte) [][]byte {
+		seq := requestSeq(request)
+		if broken {
+			// payload ends inside the header
+			return [][]byte{v5Frame(seq, []byte{0x02, 0x01, 0x10})}
+		}
+
+		frame := responseFrame(seq, readReply(1))
+		binary.LittleEndian.PutUint32(frame[frameHeaderLen+6:], 3600) // power on time
+		frame[len(frame)-2] = calcCheckSum8(frame[1 : len(frame)-2])
+		return [][]byte{frame}
+	})
+
+	if _, err := inv.Read(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if meta := inv.LastResponseMeta(); meta.Status != StatusOK || meta.PowerOnTime.Hours() != 1 {
+		t.Fatalf("got %+v", meta)
+	}
+
+	broken = true
+	if _, err := inv.Read(0, 1); !errors.Is(err, ErrShortFrame) {
+		t.Fatalf("got %v, want ErrShortFrame", err)
+	}
+	if meta := inv.LastResponseMeta(); meta != (ResponseMeta{}) {
+		t.Fatalf("meta of a broken header kept: %+v", meta)
+	}
+}
@@ -0,0 +1,42 @@
+package solarman
+
+import (
+	"context"
+	"net"
+)
+
+// -----------------------------------------------------------------------------
+// Pluggable transport
+// -----------------------------------------------------------------------------
+
+// Dialer opens the connection to the logger. *net.Dialer satisfies it,
+// custom implementations may tunnel through an SSH jump host, go through
+// a serial-to-TCP bridge, return one end of net.Pipe or wrap the connection.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// DialerFunc adapts an ordinary function to the Dialer interface
+type DialerFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (f DialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
+
+// SetDialer replaces the default TCP dialer.
+// The current connection, if any, is closed so the next request uses the new transport.
+// Passing nil restores the default.
+func (inv *InverterLogger) SetDialer(dialer Dialer) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.closeConn("dialer_changed")
+	inv.dialer = dialer
+}
+
+func (inv *InverterLogger) getDialer() Dialer {
+	if inv.dialer != nil {
+		return inv.dialer
+	}
+	return &net.Dialer{Timeout: inv.Timeout}
+}
@@ -0,0 +1,111 @@
+package solarman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Write verification
+// -----------------------------------------------------------------------------
+
+// DateTimeVerifyTolerance is the clock difference accepted when
+// SetDateTime reads back the inverter time
+const DateTimeVerifyTolerance = 2 * time.Second
+
+// VerifyError is returned when the registers read back after a write
+// differ from the written values, e.g. the inverter clamped a setting
+type VerifyError struct {
+	Start     int      // first written register
+	Requested []uint16 // written values
+	Actual    []uint16 // values read back
+}
+
+func (e *VerifyError) Error() string {
+	var mismatches []string
+	for i := range e.Requested {
+		if i < len(e.Actual) && e.Requested[i] != e.Actual[i] {
+			mismatches = append(mismatches, fmt.Sprintf("0x%X: requested %d, actual %d", e.Start+i, e.Requested[i], e.Actual[i]))
+		}
+	}
+	return "write verification mismatch: " + strings.Join(mismatches, "; ")
+}
+
+// Mismatches returns the registers read back with a value other than written,
+// mapped to [requested, actual]
+func (e *VerifyError) Mismatches() map[int][2]uint16 {
+	res := make(map[int][2]uint16)
+	for i := range e.Requested {
+		if i < len(e.Actual) && e.Requested[i] != e.Actual[i] {
+			res[e.Start+i] = [2]uint16{e.Requested[i], e.Actual[i]}
+		}
+	}
+	return res
+}
+
+// SetWriteVerify enables reading back the registers after Write, WriteSingle
+// and SetDateTime, a difference is reported as VerifyError
+func (inv *InverterLogger) SetWriteVerify(enable bool) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.VerifyWrites = enable
+}
+
+// verifyRegisters reads back written holding registers, inv.mu must be held
+func (inv *InverterLogger) verifyRegisters(ctx context.Context, startRegister int, values []int) error {
+	registers, err := inv.readRegisters(ctx, FuncReadHoldingRegisters, startRegister, len(values))
+	if err != nil {
+		return inv.error("verifyRegisters.readRegisters", "read back failed", err)
+	}
+
+	verifyErr := &VerifyError{
+		Start:     startRegister,
+		Requested: make([]uint16, len(values)),
+		Actual:    make([]uint16, len(values)),
+	}
+
+	mismatch := false
+	for i, value := range values {
+		verifyErr.Requested[i] = uint16(value)
+		verifyErr.Actual[i] = registers[startRegister+i]
+		if verifyErr.Requested[i] != verifyErr.Actual[i] {
+			mismatch = true
+		}
+	}
+
+	if mismatch {
+		return verifyErr
+	}
+
+	return nil
+}
+
+// verifyDateTime reads back the inverter clock set to timeSet at writtenAt.
+// The clock keeps running, so it is compared with timeSet advanced by the time passed.
+func (inv *InverterLogger) verifyDateTime(ctx context.Context, startRegister int, timeSet time.Time, writtenAt time.Time) error {
+	registers, err := inv.readRegisters(ctx, FuncReadHoldingRegisters, startRegister, 3)
+	if err != nil {
+		return inv.error("verifyDateTime.readRegisters", "read back failed", err)
+	}
+
+	actual := []int{int(registers[startRegister]), int(registers[startRegister+1]), int(registers[startRegister+2])}
+
+	actualTime, err := inv.bytesToLocalTime(actual)
+	if err != nil {
+		return err
+	}
+
+	diff := actualTime.Sub(timeSet.Add(time.Since(writtenAt)))
+	if diff < -DateTimeVerifyTolerance || diff > DateTimeVerifyTolerance {
+		requested := inv.localTimeToBytes(timeSet)
+		return &VerifyError{
+			Start:     startRegister,
+			Requested: []uint16{uint16(requested[0]), uint16(requested[1]), uint16(requested[2])},
+			Actual:    []uint16{uint16(actual[0]), uint16(actual[1]), uint16(actual[2])},
+		}
+	}
+
+	return nil
+}
@@ -17,7 +17,7 @@ type WriteRequestPayload struct {
 	PowerOnTime  uint32 // Device uptime (if needed)
 	OffsetTime   uint32 // Time offset (if needed)
 
-	DeviceAddress   uint8    // Device address, usually 0x01
+	DeviceAddress   uint8    // Modbus slave address, InverterLogger.SlaveID
 	FunctionCode    uint8    // Function code for writing multiple registers = 0x10
 	RegisterAddress uint16   // Starting register address
 	RegisterValues  []uint16 // List of values to write (2 bytes per register)
@@ -30,8 +30,8 @@ func (inv *InverterLogger) NewWriteRequestPayload(registerAddress uint16, values
 		DeliveryTime:    0x00000000,
 		PowerOnTime:     0x00000000,
 		OffsetTime:      0x00000000,
-		DeviceAddress:   0x01,
-		FunctionCode:    0x10, // Write Multiple Registers
+		DeviceAddress:   inv.SlaveID,
+		FunctionCode:    FuncWriteMultipleRegisters,
 		RegisterAddress: registerAddress,
 		RegisterValues:  values,
 	}
@@ -112,45 +112,53 @@ func (w *WriteRequestPayload) MarshalBinary() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// parseWriteResponse processes the server response in V5 format.
+// parseWriteResponse processes the Modbus part of the server response in V5 format.
 
-func (inv *InverterLogger) parseWriteResponse(responsePayload []byte, values []int) (int, int, error) {
-	// Look for the start of the Modbus response (should contain 01 10)
+func (inv *InverterLogger) parseWriteResponse(responsePayload []byte, deviceAddress uint8, values []int) (int, int, error) {
+	// Look for the start of the Modbus response (should contain <address> 10, or <address> 90 for exception)
 	startIndex := -1
 	for i := 0; i < len(responsePayload)-2; i++ {
-		if responsePayload[i] == 0x01 && responsePayload[i+1] == 0x10 {
+		if responsePayload[i] == deviceAddress && responsePayload[i+1]&^0x80 == 0x10 {
 			startIndex = i
 			break
 		}
 	}
 
 	if startIndex == -1 {
-		return 0, 0, fmt.Errorf("Modbus response not found in payload")
+		return 0, 0, fmt.Errorf("%w: Modbus response not found in payload", ErrUnexpectedResponse)
 	}
 
-	// Make sure the response contains at least 8 bytes after `01 10`
+	if isException(responsePayload[startIndex+1]) {
+		exception, err := unmarshalException(responsePayload[startIndex:])
+		if err != nil {
+			return 0, 0, fmt.Errorf("exception response unmarshal failed: %w", err)
+		}
+		return 0, 0, exception
+	}
+
+	// Make sure the response contains at least 8 bytes after `<address> 10`
 	if len(responsePayload[startIndex:]) < 8 {
-		return 0, 0, fmt.Errorf("unexpected response length: %d bytes, expected at least 8", len(responsePayload[startIndex:]))
+		return 0, 0, fmt.Errorf("%w: unexpected response length: %d bytes, expected at least 8", ErrShortFrame, len(responsePayload[startIndex:]))
 	}
 
-	// Fetch only Modbus-part (8 bytes after 01 10)
+	// Fetch only Modbus-part (8 bytes from <address> 10)
 	modbusResponse := responsePayload[startIndex : startIndex+8]
 	buf := bytes.NewBuffer(modbusResponse)
 
-	var deviceAddress, functionCode uint8
+	var respDeviceAddress, functionCode uint8
 	var respStartAddress, respQuantity uint16
 
-	if err := binary.Read(buf, binary.BigEndian, &deviceAddress); err != nil {
-		return 0, 0, fmt.Errorf("failed to read deviceAddress: %w", err)
+	if err := binary.Read(buf, binary.BigEndian, &respDeviceAddress); err != nil {
+		return 0, 0, fmt.Errorf("failed to read deviceAddress: %w", shortFrame(err))
 	}
 	if err := binary.Read(buf, binary.BigEndian, &functionCode); err != nil {
-		return 0, 0, fmt.Errorf("failed to read functionCode: %w", err)
+		return 0, 0, fmt.Errorf("failed to read functionCode: %w", shortFrame(err))
 	}
 	if err := binary.Read(buf, binary.BigEndian, &respStartAddress); err != nil {
-		return 0, 0, fmt.Errorf("failed to read starting address: %w", err)
+		return 0, 0, fmt.Errorf("failed to read starting address: %w", shortFrame(err))
 	}
 	if err := binary.Read(buf, binary.BigEndian, &respQuantity); err != nil {
-		return 0, 0, fmt.Errorf("failed to read quantity: %w", err)
+		return 0, 0, fmt.Errorf("failed to read quantity: %w", shortFrame(err))
 	}
 
 	// Determine the extra bytes after the Modbus response
@@ -160,11 +168,11 @@ func (inv *InverterLogger) parseWriteResponse(responsePayload []byte, values []i
 	// }
 
 	// Check the correctness of the answer
-	if deviceAddress != 0x01 || functionCode != 0x10 {
-		return 0, 0, fmt.Errorf("unexpected response: deviceAddress %d, functionCode %d", deviceAddress, functionCode)
+	if respDeviceAddress != deviceAddress || functionCode != FuncWriteMultipleRegisters {
+		return 0, 0, fmt.Errorf("%w: deviceAddress %d, functionCode %d", ErrUnexpectedResponse, respDeviceAddress, functionCode)
 	}
 	if respQuantity != uint16(len(values)) {
-		return 0, 0, fmt.Errorf("unexpected quantity: expected %d, got %d", len(values), respQuantity)
+		return 0, 0, fmt.Errorf("%w: expected quantity %d, got %d", ErrUnexpectedResponse, len(values), respQuantity)
 	}
 
 	// Return number of bytes written (each register is 2 bytes) and the starting register
@@ -173,3 +181,127 @@ func (inv *InverterLogger) parseWriteResponse(responsePayload []byte, values []i
 
 	return writtenBytes, startRegister, nil
 }
+
+// -----------------------------------------------------------------------------
+// Write Single Register Request (Modbus Function 0x06)
+// -----------------------------------------------------------------------------
+
+type WriteSingleRequestPayload struct {
+	FrameType    uint8
+	SensorType   uint16
+	DeliveryTime uint32
+	PowerOnTime  uint32
+	OffsetTime   uint32
+
+	DeviceAddress   uint8  // Modbus slave address, InverterLogger.SlaveID
+	FunctionCode    uint8  // Function code for writing single register = 0x06
+	RegisterAddress uint16 // Register address
+	RegisterValue   uint16 // Value to write
+}
+
+func (inv *InverterLogger) NewWriteSingleRequestPayload(registerAddress uint16, value uint16) *WriteSingleRequestPayload {
+	return &WriteSingleRequestPayload{
+		FrameType:       0x02,
+		SensorType:      0x0000,
+		DeliveryTime:    0x00000000,
+		PowerOnTime:     0x00000000,
+		OffsetTime:      0x00000000,
+		DeviceAddress:   inv.SlaveID,
+		FunctionCode:    FuncWriteSingleRegister,
+		RegisterAddress: registerAddress,
+		RegisterValue:   value,
+	}
+}
+
+func (w *WriteSingleRequestPayload) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := marshalRequestHeader(&buf, w.FrameType, w.SensorType, w.DeliveryTime, w.PowerOnTime, w.OffsetTime); err != nil {
+		return nil, err
+	}
+
+	// 1 byte: DeviceAddress
+	// 1 byte: FunctionCode (0x06)
+	// 2 bytes: Register address (Big Endian)
+	// 2 bytes: Register value (Big Endian)
+	// 2 bytes: CRC16 (Little Endian)
+	buf.Write(marshalModbusWords(w.DeviceAddress, w.FunctionCode, w.RegisterAddress, w.RegisterValue))
+
+	return buf.Bytes(), nil
+}
+
+// marshalRequestHeader writes the V5 request payload header
+func marshalRequestHeader(buf *bytes.Buffer, frameType uint8, sensorType uint16, deliveryTime, powerOnTime, offsetTime uint32) error {
+	for _, field := range []any{frameType, sensorType, deliveryTime, powerOnTime, offsetTime} {
+		if err := binary.Write(buf, binary.LittleEndian, field); err != nil {
+			return fmt.Errorf("request header binary Write failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// marshalModbusWords builds a Modbus RTU frame of address, function code,
+// Big Endian words and CRC16
+func marshalModbusWords(deviceAddress uint8, functionCode uint8, words ...uint16) []byte {
+	frame := []byte{deviceAddress, functionCode}
+	for _, word := range words {
+		frame = append(frame, byte(word>>8), byte(word))
+	}
+
+	crc := calcCRC16Modbus(frame)
+	return append(frame, byte(crc), byte(crc>>8))
+}
+
+// EchoResponse is the Modbus reply of single/multiple write functions
+// (0x05, 0x06, 0x0F, 0x10): two words echoing the request
+type EchoResponse struct {
+	DeviceAddress uint8
+	FunctionCode  uint8
+	Address       uint16 // register or coil address
+	Value         uint16 // written value (0x05, 0x06) or quantity (0x0F, 0x10)
+}
+
+// parseEchoResponse decodes the Modbus part of a write reply:
+// 1 byte: DeviceAddress
+// 1 byte: FunctionCode
+// 2 bytes: Address (Big Endian)
+// 2 bytes: Value or quantity (Big Endian)
+// 2 bytes: CRC16 (Little Endian)
+func parseEchoResponse(modbus []byte) (*EchoResponse, error) {
+	if len(modbus) >= 2 && isException(modbus[1]) {
+		exception, err := unmarshalException(modbus)
+		if err != nil {
+			return nil, fmt.Errorf("exception response unmarshal failed: %w", err)
+		}
+		return nil, exception
+	}
+
+	if len(modbus) < 8 {
+		return nil, fmt.Errorf("%w: echo response of %d bytes, expected 8", ErrShortFrame, len(modbus))
+	}
+
+	crc := binary.LittleEndian.Uint16(modbus[6:8])
+	if expectedCRC := calcCRC16Modbus(modbus[:6]); crc != expectedCRC {
+		return nil, fmt.Errorf("%w: expected 0x%X, got 0x%X", ErrCRC, expectedCRC, crc)
+	}
+
+	return &EchoResponse{
+		DeviceAddress: modbus[0],
+		FunctionCode:  modbus[1],
+		Address:       binary.BigEndian.Uint16(modbus[2:4]),
+		Value:         binary.BigEndian.Uint16(modbus[4:6]),
+	}, nil
+}
+
+// matches checks the echo against the expected reply
+func (e *EchoResponse) matches(deviceAddress uint8, functionCode uint8, address uint16, value uint16) error {
+	if e.DeviceAddress != deviceAddress || e.FunctionCode != functionCode {
+		return fmt.Errorf("%w: deviceAddress %d, functionCode 0x%02X, expected %d, 0x%02X",
+			ErrUnexpectedResponse, e.DeviceAddress, e.FunctionCode, deviceAddress, functionCode)
+	}
+	if e.Address != address || e.Value != value {
+		return fmt.Errorf("%w: echo 0x%04X=0x%04X, expected 0x%04X=0x%04X",
+			ErrUnexpectedResponse, e.Address, e.Value, address, value)
+	}
+	return nil
+}